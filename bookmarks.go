@@ -0,0 +1,203 @@
+package opml
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// ParseBookmarks reads a Netscape bookmark file, the HTML format browsers
+// use to import and export bookmarks. Folders become outlines with
+// children, bookmarks become link outlines and bookmarks carrying a
+// FEEDURL become rss outlines.
+func ParseBookmarks(r io.Reader) (*OPML, error) {
+	o := &OPML{Version: "2.0"}
+	z := xhtml.NewTokenizer(r)
+
+	stack := []*[]*Outline{&o.Outlines}
+	var folder, last *Outline
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case xhtml.ErrorToken:
+			if z.Err() == io.EOF {
+				return o, nil
+			}
+			return nil, z.Err()
+		case xhtml.StartTagToken:
+			tok := z.Token()
+			current := stack[len(stack)-1]
+			switch tok.Data {
+			case "title":
+				o.Title = bookmarkText(z, "title")
+			case "dl":
+				if folder != nil {
+					stack = append(stack, &folder.Outlines)
+					folder = nil
+				} else {
+					stack = append(stack, current)
+				}
+			case "h3":
+				folder = &Outline{Text: bookmarkText(z, "h3")}
+				folder.Created = bookmarkTime(tok, "add_date")
+				*current = append(*current, folder)
+				last = folder
+			case "a":
+				b, err := bookmarkOutline(tok)
+				if err != nil {
+					return nil, err
+				}
+				b.Text = bookmarkText(z, "a")
+				*current = append(*current, b)
+				folder, last = nil, b
+			case "dd":
+				if last != nil {
+					last.Description = bookmarkText(z, "")
+				}
+			}
+		case xhtml.EndTagToken:
+			name, _ := z.TagName()
+			if string(name) == "dl" && len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
+
+func bookmarkOutline(tok xhtml.Token) (*Outline, error) {
+	o := &Outline{Type: "link", Created: bookmarkTime(tok, "add_date")}
+	for _, a := range tok.Attr {
+		switch a.Key {
+		case "href":
+			u, err := url.Parse(a.Val)
+			if err != nil {
+				return nil, err
+			}
+			o.URL = u
+		case "feedurl":
+			u, err := url.Parse(a.Val)
+			if err != nil {
+				return nil, err
+			}
+			o.XMLURL = u
+		case "tags":
+			for _, t := range strings.Split(a.Val, ",") {
+				if t = strings.TrimSpace(t); t != "" {
+					o.Categories = append(o.Categories, t)
+				}
+			}
+		}
+	}
+	if o.XMLURL != nil {
+		o.Type = "rss"
+		o.HTMLURL = o.URL
+		o.URL = nil
+	}
+	return o, nil
+}
+
+func bookmarkTime(tok xhtml.Token, key string) time.Time {
+	for _, a := range tok.Attr {
+		if a.Key == key {
+			if sec, err := strconv.ParseInt(a.Val, 10, 64); err == nil && sec > 0 {
+				return time.Unix(sec, 0).UTC()
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// bookmarkText collects text up to the end tag named end, or up to the next
+// tag when end is empty.
+func bookmarkText(z *xhtml.Tokenizer, end string) string {
+	var b strings.Builder
+	for {
+		switch z.Next() {
+		case xhtml.TextToken:
+			b.Write(z.Text())
+			if end == "" {
+				return strings.TrimSpace(b.String())
+			}
+		case xhtml.EndTagToken:
+			if name, _ := z.TagName(); string(name) == end {
+				return strings.TrimSpace(b.String())
+			}
+		case xhtml.ErrorToken:
+			return strings.TrimSpace(b.String())
+		default:
+			if end == "" {
+				return strings.TrimSpace(b.String())
+			}
+		}
+	}
+}
+
+func RenderBookmarks(w io.Writer, opml *OPML) error {
+	bw := bufio.NewWriter(w)
+	title := opml.Title
+	if title == "" {
+		title = "Bookmarks"
+	}
+	fmt.Fprintln(bw, "<!DOCTYPE NETSCAPE-Bookmark-file-1>")
+	fmt.Fprintln(bw, `<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">`)
+	fmt.Fprintf(bw, "<TITLE>%s</TITLE>\n", html.EscapeString(title))
+	fmt.Fprintf(bw, "<H1>%s</H1>\n", html.EscapeString(title))
+	renderBookmarks(bw, opml.Outlines, "")
+	return bw.Flush()
+}
+
+func renderBookmarks(w *bufio.Writer, os []*Outline, indent string) {
+	fmt.Fprintf(w, "%s<DL><p>\n", indent)
+	for _, o := range os {
+		href := o.URL
+		if href == nil {
+			href = o.HTMLURL
+		}
+		if href == nil {
+			href = o.XMLURL
+		}
+
+		fmt.Fprintf(w, "%s    <DT>", indent)
+		if href != nil && len(o.Outlines) == 0 {
+			fmt.Fprintf(w, `<A HREF="%s"`, html.EscapeString(href.String()))
+			if o.XMLURL != nil && href != o.XMLURL {
+				fmt.Fprintf(w, ` FEEDURL="%s"`, html.EscapeString(o.XMLURL.String()))
+			}
+			writeBookmarkAttrs(w, o)
+			fmt.Fprintf(w, ">%s</A>\n", html.EscapeString(o.Text))
+			writeBookmarkDescription(w, o, indent)
+		} else {
+			fmt.Fprint(w, "<H3")
+			writeBookmarkAttrs(w, o)
+			fmt.Fprintf(w, ">%s</H3>\n", html.EscapeString(o.Text))
+			writeBookmarkDescription(w, o, indent)
+			renderBookmarks(w, o.Outlines, indent+"    ")
+		}
+	}
+	fmt.Fprintf(w, "%s</DL><p>\n", indent)
+}
+
+// writeBookmarkDescription writes the description of o, which must come
+// right after its title: a folder's comes before its list.
+func writeBookmarkDescription(w *bufio.Writer, o *Outline, indent string) {
+	if o.Description != "" {
+		fmt.Fprintf(w, "%s    <DD>%s\n", indent, html.EscapeString(o.Description))
+	}
+}
+
+func writeBookmarkAttrs(w *bufio.Writer, o *Outline) {
+	if !o.Created.IsZero() {
+		fmt.Fprintf(w, ` ADD_DATE="%d"`, o.Created.Unix())
+	}
+	if len(o.Categories) > 0 {
+		fmt.Fprintf(w, ` TAGS="%s"`, html.EscapeString(strings.Join(o.Categories, ",")))
+	}
+}
@@ -0,0 +1,84 @@
+package opml
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var bookmarks = &OPML{
+	Version: "2.0",
+	Title:   "Bookmarks",
+	Outlines: []*Outline{
+		{
+			Text:    "News",
+			Created: time.Date(2005, 10, 9, 12, 8, 9, 0, time.UTC),
+			Outlines: []*Outline{
+				{
+					Text:    "Scripting News",
+					Type:    "rss",
+					Created: time.Date(2005, 10, 9, 12, 8, 9, 0, time.UTC),
+					XMLURL:  parseURL("http://www.scripting.com/rss.xml"),
+					HTMLURL: parseURL("http://www.scripting.com/"),
+				},
+				{
+					Text:        "CNET News.com",
+					Type:        "link",
+					Categories:  []string{"tech", "news"},
+					Description: "Tech news and business reports",
+					URL:         parseURL("http://news.com.com/"),
+				},
+			},
+		},
+		{
+			Text: "OPML & friends",
+			Type: "link",
+			URL:  parseURL("http://www.opml.org/"),
+		},
+	},
+}
+
+func TestParseBookmarks(t *testing.T) {
+	got, err := ParseBookmarks(openTestData("bookmarks.html"))
+	if err != nil {
+		t.Error("Failed to parse bookmarks:", err)
+	}
+
+	if !reflect.DeepEqual(bookmarks, got) {
+		t.Errorf("OPML mismatch\nexpected: %#v\ngot: %#v\n", bookmarks, got)
+	}
+}
+
+func TestRenderBookmarks(t *testing.T) {
+	folderDescription := &OPML{
+		Version: "2.0",
+		Title:   "Bookmarks",
+		Outlines: []*Outline{
+			{
+				Text:        "News",
+				Description: "Daily reading",
+				Outlines: []*Outline{
+					{Text: "OPML", Type: "link", URL: parseURL("http://www.opml.org/")},
+				},
+			},
+		},
+	}
+
+	for _, want := range []*OPML{bookmarks, folderDescription} {
+		var buf bytes.Buffer
+		err := RenderBookmarks(&buf, want)
+		if err != nil {
+			t.Error("Failed to render bookmarks:", err)
+		}
+
+		got, err := ParseBookmarks(&buf)
+		if err != nil {
+			t.Error("Failed to parse bookmarks:", err)
+		}
+
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("OPML mismatch\nexpected: %#v\ngot: %#v\n", want, got)
+		}
+	}
+}
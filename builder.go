@@ -0,0 +1,193 @@
+package opml
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// A BuildError lists the problems found while building a document.
+type BuildError struct {
+	Errs []error
+}
+
+func (e *BuildError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return "opml: " + strings.Join(msgs, "; ")
+}
+
+type buildState struct {
+	errs []error
+}
+
+func (s *buildState) errorf(path []string, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if len(path) > 0 {
+		msg = strings.Join(path, "/") + ": " + msg
+	}
+	s.errs = append(s.errs, errors.New(msg))
+}
+
+// parseURL parses an optional URL attribute. Required URLs must not be
+// empty, and all must be absolute.
+func (s *buildState) parseURL(path []string, name, rawurl string, required bool) *url.URL {
+	if rawurl == "" {
+		if required {
+			s.errorf(path, "%s is required", name)
+		}
+		return nil
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		s.errorf(path, "invalid %s: %v", name, err)
+		return nil
+	}
+	if !u.IsAbs() {
+		s.errorf(path, "%s %q is not absolute", name, rawurl)
+		return nil
+	}
+	return u
+}
+
+// A FolderBuilder adds outlines to a folder, or to the top level of a
+// document.
+type FolderBuilder struct {
+	state    *buildState
+	path     []string
+	outlines *[]*Outline
+}
+
+func (f *FolderBuilder) add(o *Outline) {
+	*f.outlines = append(*f.outlines, o)
+}
+
+// Folder adds a folder and calls fn to fill it.
+func (f *FolderBuilder) Folder(text string, fn func(f *FolderBuilder)) *FolderBuilder {
+	o := &Outline{Text: text}
+	if text == "" {
+		f.state.errorf(f.path, "folder text is required")
+	}
+	f.add(o)
+	if fn != nil {
+		fn(&FolderBuilder{state: f.state, path: append(f.path[:len(f.path):len(f.path)], text), outlines: &o.Outlines})
+	}
+	return f
+}
+
+// RSS adds a feed. htmlURL may be empty.
+func (f *FolderBuilder) RSS(text, xmlURL, htmlURL string) *FolderBuilder {
+	path := append(f.path[:len(f.path):len(f.path)], text)
+	if text == "" {
+		f.state.errorf(path, "rss text is required")
+	}
+	f.add(&Outline{
+		Text:    text,
+		Type:    "rss",
+		XMLURL:  f.state.parseURL(path, "xmlUrl", xmlURL, true),
+		HTMLURL: f.state.parseURL(path, "htmlUrl", htmlURL, false),
+	})
+	return f
+}
+
+func (f *FolderBuilder) Link(text, rawurl string) *FolderBuilder {
+	path := append(f.path[:len(f.path):len(f.path)], text)
+	f.add(&Outline{Text: text, Type: "link", URL: f.state.parseURL(path, "url", rawurl, true)})
+	return f
+}
+
+// Include adds an outline that includes the OPML document at rawurl.
+func (f *FolderBuilder) Include(text, rawurl string) *FolderBuilder {
+	path := append(f.path[:len(f.path):len(f.path)], text)
+	f.add(&Outline{Text: text, Type: "include", URL: f.state.parseURL(path, "url", rawurl, true)})
+	return f
+}
+
+// Outline adds o as it is.
+func (f *FolderBuilder) Outline(o *Outline) *FolderBuilder {
+	f.add(o)
+	return f
+}
+
+// A Builder builds a document, collecting errors until Build.
+type Builder struct {
+	doc   *OPML
+	top   *FolderBuilder
+	state *buildState
+}
+
+func NewDocument() *Builder {
+	b := &Builder{doc: &OPML{Version: "2.0"}, state: &buildState{}}
+	b.top = &FolderBuilder{state: b.state, outlines: &b.doc.Outlines}
+	return b
+}
+
+func (b *Builder) Title(title string) *Builder {
+	b.doc.Title = title
+	return b
+}
+
+// Owner sets the owner's name and email, either of which may be empty.
+func (b *Builder) Owner(name, email string) *Builder {
+	b.doc.OwnerName = name
+	b.doc.OwnerEmail = email
+	return b
+}
+
+func (b *Builder) OwnerID(rawurl string) *Builder {
+	b.doc.OwnerID = b.state.parseURL(nil, "ownerId", rawurl, false)
+	return b
+}
+
+func (b *Builder) Docs(rawurl string) *Builder {
+	b.doc.Docs = b.state.parseURL(nil, "docs", rawurl, false)
+	return b
+}
+
+func (b *Builder) DateCreated(t time.Time) *Builder {
+	b.doc.DateCreated = t
+	return b
+}
+
+func (b *Builder) DateModified(t time.Time) *Builder {
+	b.doc.DateModified = t
+	return b
+}
+
+func (b *Builder) Folder(text string, fn func(f *FolderBuilder)) *Builder {
+	b.top.Folder(text, fn)
+	return b
+}
+
+func (b *Builder) RSS(text, xmlURL, htmlURL string) *Builder {
+	b.top.RSS(text, xmlURL, htmlURL)
+	return b
+}
+
+func (b *Builder) Link(text, rawurl string) *Builder {
+	b.top.Link(text, rawurl)
+	return b
+}
+
+func (b *Builder) Include(text, rawurl string) *Builder {
+	b.top.Include(text, rawurl)
+	return b
+}
+
+func (b *Builder) Outline(o *Outline) *Builder {
+	b.top.Outline(o)
+	return b
+}
+
+// Build returns the document, or a *BuildError listing every problem
+// found while building it.
+func (b *Builder) Build() (*OPML, error) {
+	if len(b.state.errs) > 0 {
+		return nil, &BuildError{Errs: b.state.errs}
+	}
+	return b.doc, nil
+}
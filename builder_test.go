@@ -0,0 +1,90 @@
+package opml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuilder(t *testing.T) {
+	created := time.Date(2005, 6, 18, 12, 11, 52, 0, time.UTC)
+	doc, err := NewDocument().
+		Title("mySubscriptions.opml").
+		Owner("Dave Winer", "dave@scripting.com").
+		OwnerID("http://www.opml.org/profiles/sendMail?usernum=1").
+		DateCreated(created).
+		Folder("Tech", func(f *FolderBuilder) {
+			f.RSS("Scripting News", "http://www.scripting.com/rss.xml", "http://www.scripting.com/")
+			f.Folder("Go", func(f *FolderBuilder) {
+				f.RSS("Go Blog", "https://go.dev/blog/feed.atom", "")
+			})
+		}).
+		Link("OPML", "http://opml.org/").
+		Include("Blogroll", "http://example.com/blogroll.opml").
+		Build()
+	if err != nil {
+		t.Fatal("Failed to build:", err)
+	}
+
+	want := &OPML{
+		Version:     "2.0",
+		Title:       "mySubscriptions.opml",
+		OwnerName:   "Dave Winer",
+		OwnerEmail:  "dave@scripting.com",
+		OwnerID:     parseURL("http://www.opml.org/profiles/sendMail?usernum=1"),
+		DateCreated: created,
+		Outlines: []*Outline{
+			{Text: "Tech", Outlines: []*Outline{
+				{Text: "Scripting News", Type: "rss", XMLURL: parseURL("http://www.scripting.com/rss.xml"), HTMLURL: parseURL("http://www.scripting.com/")},
+				{Text: "Go", Outlines: []*Outline{
+					{Text: "Go Blog", Type: "rss", XMLURL: parseURL("https://go.dev/blog/feed.atom")},
+				}},
+			}},
+			{Text: "OPML", Type: "link", URL: parseURL("http://opml.org/")},
+			{Text: "Blogroll", Type: "include", URL: parseURL("http://example.com/blogroll.opml")},
+		},
+	}
+	if !reflect.DeepEqual(want, doc) {
+		t.Errorf("OPML mismatch\nexpected: %#v\ngot: %#v\n", want, doc)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	_, err := NewDocument().
+		Docs("not a url").
+		Folder("Tech", func(f *FolderBuilder) {
+			f.RSS("No feed", "", "")
+			f.RSS("Bad", "http://example.com/%zz", "relative/path")
+		}).
+		Folder("", nil).
+		Link("Nowhere", "").
+		Build()
+
+	e, ok := err.(*BuildError)
+	if !ok {
+		t.Fatalf("Error mismatch\nexpected: *BuildError\ngot: %v\n", err)
+	}
+	// The text of url.Parse errors varies between Go versions, so only the
+	// start of that message is compared.
+	const invalidURL = `Tech/Bad: invalid xmlUrl: `
+	var got []string
+	for _, err := range e.Errs {
+		msg := err.Error()
+		if strings.HasPrefix(msg, invalidURL) {
+			msg = invalidURL + "..."
+		}
+		got = append(got, msg)
+	}
+	want := []string{
+		`docs "not a url" is not absolute`,
+		`Tech/No feed: xmlUrl is required`,
+		invalidURL + "...",
+		`Tech/Bad: htmlUrl "relative/path" is not absolute`,
+		`folder text is required`,
+		`Nowhere: url is required`,
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Errors mismatch\nexpected: %q\ngot: %q\n", want, got)
+	}
+}
@@ -0,0 +1,176 @@
+package opml
+
+import (
+	"sort"
+	"strings"
+)
+
+// A Category is a category of an outline. Categories are slash-delimited
+// paths such as "/Boston/Weather", or tags without slashes. A category
+// written as an absolute URL, such as "http://example.com/cusips/MSFT", has
+// the scheme and host as Domain and the URL path as Path.
+type Category struct {
+	Domain string
+	Path   []string
+	// Tag is set for categories without slashes.
+	Tag bool
+}
+
+func ParseCategory(s string) Category {
+	var c Category
+	if i := strings.Index(s, "://"); i >= 0 {
+		rest := s[i+3:]
+		j := strings.Index(rest, "/")
+		if j < 0 {
+			j = len(rest)
+		}
+		c.Domain, s = s[:i+3+j], rest[j:]
+	}
+	if c.Domain == "" && !strings.Contains(s, "/") {
+		if s != "" {
+			c.Path = []string{s}
+			c.Tag = true
+		}
+		return c
+	}
+	for _, segment := range strings.Split(s, "/") {
+		if segment != "" {
+			c.Path = append(c.Path, segment)
+		}
+	}
+	return c
+}
+
+func (c Category) String() string {
+	if c.Tag && len(c.Path) == 1 {
+		return c.Path[0]
+	}
+	return c.Domain + "/" + strings.Join(c.Path, "/")
+}
+
+func (c Category) Equal(other Category) bool {
+	return c.String() == other.String()
+}
+
+// HasPrefix reports whether c is prefix or a category under it. A tag
+// only has itself as a prefix, besides the root "/" every category of the
+// domain has.
+func (c Category) HasPrefix(prefix Category) bool {
+	if c.Domain != prefix.Domain || len(c.Path) < len(prefix.Path) {
+		return false
+	}
+	if root := len(prefix.Path) == 0 && !prefix.Tag; !root && c.Tag != prefix.Tag {
+		return false
+	}
+	for i, segment := range prefix.Path {
+		if c.Path[i] != segment {
+			return false
+		}
+	}
+	return true
+}
+
+// ParsedCategories returns the categories of the outline.
+func (o *Outline) ParsedCategories() []Category {
+	categories := make([]Category, 0, len(o.Categories))
+	for _, s := range o.Categories {
+		categories = append(categories, ParseCategory(s))
+	}
+	return categories
+}
+
+// SetCategories replaces the categories of the outline, dropping
+// duplicates.
+func (o *Outline) SetCategories(categories ...Category) {
+	o.Categories = nil
+	seen := map[string]bool{}
+	for _, c := range categories {
+		s := c.String()
+		if !seen[s] {
+			seen[s] = true
+			o.Categories = append(o.Categories, s)
+		}
+	}
+}
+
+type CategoryCount struct {
+	Category Category
+	// Count is the number of outlines with the category.
+	Count int
+}
+
+// Categories lists the categories used in the document, sorted by their
+// string form.
+func (o *OPML) Categories() []CategoryCount {
+	counts := map[string]*CategoryCount{}
+	o.Walk(PreOrder, func(n Node) error {
+		seen := map[string]bool{}
+		for _, c := range n.Outline.ParsedCategories() {
+			s := c.String()
+			if seen[s] {
+				continue
+			}
+			seen[s] = true
+			if counts[s] == nil {
+				counts[s] = &CategoryCount{Category: c}
+			}
+			counts[s].Count++
+		}
+		return nil
+	})
+
+	list := make([]CategoryCount, 0, len(counts))
+	for _, c := range counts {
+		list = append(list, *c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Category.String() < list[j].Category.String() })
+	return list
+}
+
+// RenameCategory replaces old, and the prefix old of the categories under
+// it, with new on every outline. Renaming to a category an outline already
+// has merges the two. It returns the number of outlines changed.
+func (o *OPML) RenameCategory(old, new Category) int {
+	changed := 0
+	o.Walk(PreOrder, func(n Node) error {
+		categories := n.Outline.ParsedCategories()
+		renamed := false
+		for i, c := range categories {
+			if !c.HasPrefix(old) {
+				continue
+			}
+			r := Category{Domain: new.Domain, Path: append(append([]string{}, new.Path...), c.Path[len(old.Path):]...)}
+			r.Tag = new.Tag && len(r.Path) == 1
+			categories[i] = r
+			renamed = true
+		}
+		if renamed {
+			n.Outline.SetCategories(categories...)
+			changed++
+		}
+		return nil
+	})
+	return changed
+}
+
+// MergeCategories renames each of from to into.
+func (o *OPML) MergeCategories(into Category, from ...Category) int {
+	changed := 0
+	for _, c := range from {
+		changed += o.RenameCategory(c, into)
+	}
+	return changed
+}
+
+// FindByCategory returns the outlines with prefix, or a category under it,
+// in document order.
+func (o *OPML) FindByCategory(prefix Category) []Node {
+	return o.FindAll(func(n Node) bool {
+		for _, c := range n.Outline.ParsedCategories() {
+			if c.HasPrefix(prefix) {
+				return true
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,119 @@
+package opml
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseCategoryPath(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Category
+	}{
+		{"/Boston/Weather", Category{Path: []string{"Boston", "Weather"}}},
+		{"/Tourism/New York", Category{Path: []string{"Tourism", "New York"}}},
+		{"baseball", Category{Path: []string{"baseball"}, Tag: true}},
+		{"http://www.fool.com/cusips/MSFT", Category{Domain: "http://www.fool.com", Path: []string{"cusips", "MSFT"}}},
+	}
+	for _, test := range tests {
+		got := ParseCategory(test.s)
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("Category mismatch for %q\nexpected: %#v\ngot: %#v\n", test.s, test.want, got)
+		}
+		if s := got.String(); s != test.s {
+			t.Errorf("String mismatch\nexpected: %s\ngot: %s\n", test.s, s)
+		}
+	}
+}
+
+func TestCategoryHasPrefix(t *testing.T) {
+	c := ParseCategory("/Philosophy/Baseball/Mets")
+	for _, prefix := range []string{"/", "/Philosophy", "/Philosophy/Baseball/Mets"} {
+		if !c.HasPrefix(ParseCategory(prefix)) {
+			t.Errorf("Expected %s to have prefix %q", c, prefix)
+		}
+	}
+	for _, prefix := range []string{"/Phil", "/Baseball", "/Philosophy/Baseball/Mets/2005", "http://example.com/Philosophy", "Philosophy"} {
+		if c.HasPrefix(ParseCategory(prefix)) {
+			t.Errorf("Expected %s not to have prefix %q", c, prefix)
+		}
+	}
+
+	tag := ParseCategory("baseball")
+	if !tag.HasPrefix(tag) || !tag.HasPrefix(ParseCategory("/")) {
+		t.Errorf("Expected %s to have prefixes %q and %q", tag, tag, "/")
+	}
+	if tag.HasPrefix(ParseCategory("/baseball")) {
+		t.Errorf("Expected %s not to have prefix %q", tag, "/baseball")
+	}
+}
+
+func categoryDocument(t *testing.T) *OPML {
+	f, err := os.Open("testdata/category.opml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	doc, err := Import(f)
+	if err != nil {
+		t.Fatal("Failed to parse:", err)
+	}
+	doc.Outlines = append(doc.Outlines,
+		&Outline{Text: "Yankees", Categories: []string{"/Philosophy/Baseball/Yankees", "baseball"}},
+		&Outline{Text: "Red Sox", Categories: []string{"/Tourism/Boston", "baseball"}},
+	)
+	return doc
+}
+
+func TestCategories(t *testing.T) {
+	doc := categoryDocument(t)
+
+	var got []string
+	for _, c := range doc.Categories() {
+		got = append(got, fmt.Sprintf("%s:%d", c.Category, c.Count))
+	}
+	want := []string{"/Philosophy/Baseball/Mets:1", "/Philosophy/Baseball/Yankees:1", "/Tourism/Boston:1", "/Tourism/New York:1", "baseball:2"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Categories mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+}
+
+func TestRenameCategory(t *testing.T) {
+	doc := categoryDocument(t)
+
+	if n := doc.RenameCategory(ParseCategory("/Philosophy/Baseball"), ParseCategory("/Sports/MLB")); n != 2 {
+		t.Errorf("Count mismatch\nexpected: %d\ngot: %d\n", 2, n)
+	}
+	if n := doc.MergeCategories(ParseCategory("/Sports/MLB/Mets"), ParseCategory("/Sports/MLB/Yankees")); n != 1 {
+		t.Errorf("Count mismatch\nexpected: %d\ngot: %d\n", 1, n)
+	}
+	if n := doc.RenameCategory(ParseCategory("baseball"), ParseCategory("/Tourism/Boston")); n != 2 {
+		t.Errorf("Count mismatch\nexpected: %d\ngot: %d\n", 2, n)
+	}
+
+	want := [][]string{
+		{"/Sports/MLB/Mets", "/Tourism/New York"},
+		{"/Sports/MLB/Mets", "/Tourism/Boston"},
+		{"/Tourism/Boston"},
+	}
+	for i, o := range doc.Outlines {
+		if !reflect.DeepEqual(want[i], o.Categories) {
+			t.Errorf("Categories mismatch\nexpected: %v\ngot: %v\n", want[i], o.Categories)
+		}
+	}
+}
+
+func TestFindByCategory(t *testing.T) {
+	doc := categoryDocument(t)
+
+	var got []string
+	for _, n := range doc.FindByCategory(ParseCategory("/Philosophy/Baseball")) {
+		got = append(got, n.Outline.Text)
+	}
+	want := []string{"The Mets are the best team in baseball.", "Yankees"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+}
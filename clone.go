@@ -0,0 +1,53 @@
+package opml
+
+import "net/url"
+
+func cloneURL(u *url.URL) *url.URL {
+	if u == nil {
+		return nil
+	}
+	c := *u
+	if u.User != nil {
+		user := *u.User
+		c.User = &user
+	}
+	return &c
+}
+
+func cloneOutlines(os []*Outline) []*Outline {
+	if os == nil {
+		return nil
+	}
+	clones := make([]*Outline, len(os))
+	for i, o := range os {
+		clones[i] = o.Clone()
+	}
+	return clones
+}
+
+// Clone returns a deep copy of the outline that shares no URLs or slices
+// with it.
+func (o *Outline) Clone() *Outline {
+	c := *o
+	if o.Categories != nil {
+		c.Categories = append([]string{}, o.Categories...)
+	}
+	c.XMLURL = cloneURL(o.XMLURL)
+	c.HTMLURL = cloneURL(o.HTMLURL)
+	c.URL = cloneURL(o.URL)
+	c.Outlines = cloneOutlines(o.Outlines)
+	return &c
+}
+
+// Clone returns a deep copy of the document that shares no outlines, URLs
+// or slices with it.
+func (o *OPML) Clone() *OPML {
+	c := *o
+	if o.ExpansionState != nil {
+		c.ExpansionState = append([]int{}, o.ExpansionState...)
+	}
+	c.OwnerID = cloneURL(o.OwnerID)
+	c.Docs = cloneURL(o.Docs)
+	c.Outlines = cloneOutlines(o.Outlines)
+	return &c
+}
@@ -0,0 +1,40 @@
+package opml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	for _, doc := range []*OPML{subscriptionList, category, states} {
+		c := doc.Clone()
+		if !reflect.DeepEqual(doc, c) {
+			t.Errorf("OPML mismatch\nexpected: %#v\ngot: %#v\n", doc, c)
+		}
+		if c == doc || len(doc.Outlines) > 0 && c.Outlines[0] == doc.Outlines[0] {
+			t.Error("Expected the clone not to share outlines")
+		}
+	}
+
+	doc := subscriptionList.Clone()
+	c := doc.Clone()
+	c.Outlines[0].XMLURL.Host = "example.com"
+	c.Outlines[0].Categories = append(c.Outlines[0].Categories, "/Tech")
+	c.OwnerID = nil
+	c.Outlines = append(c.Outlines[:1], c.Outlines[2:]...)
+	if !reflect.DeepEqual(subscriptionList, doc) {
+		t.Errorf("OPML mismatch after changing the clone\nexpected: %#v\ngot: %#v\n", subscriptionList, doc)
+	}
+}
+
+func TestCloneOutline(t *testing.T) {
+	o := category.Outlines[0]
+	c := o.Clone()
+	if !reflect.DeepEqual(o, c) {
+		t.Errorf("Outline mismatch\nexpected: %#v\ngot: %#v\n", o, c)
+	}
+	c.Categories[0] = "/Changed"
+	if o.Categories[0] == "/Changed" {
+		t.Error("Expected the clone not to share categories")
+	}
+}
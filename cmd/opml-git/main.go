@@ -0,0 +1,196 @@
+// Command opml-git lets git merge and diff OPML files by their outlines
+// rather than line by line.
+//
+// Configure it in .gitattributes:
+//
+//	*.opml merge=opml diff=opml
+//
+// and in .git/config or ~/.gitconfig:
+//
+//	[merge "opml"]
+//		name = OPML outline merge
+//		driver = opml-git merge %O %A %B
+//	[diff "opml"]
+//		textconv = opml-git textconv
+//
+// The merge mode writes the merged document to %A and exits with status 1
+// when there are conflicts, which are marked in the document as comment
+// outlines. The textconv mode prints a document as an indented outline.
+// The diff mode prints the outline changes between two documents, and also
+// accepts the seven arguments git passes to GIT_EXTERNAL_DIFF.
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/kaorimatz/go-opml"
+)
+
+const usage = `usage: opml-git merge BASE OURS THEIRS
+       opml-git textconv FILE
+       opml-git diff OLD NEW
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var err error
+	status := 0
+	switch cmd, args := args[0], args[1:]; {
+	case cmd == "merge" && len(args) == 3:
+		status, err = merge(args[0], args[1], args[2], stderr)
+	case cmd == "textconv" && len(args) == 1:
+		err = textconv(args[0], stdout)
+	case cmd == "diff" && len(args) == 2:
+		err = diff(args[0], args[1], stdout)
+	case cmd == "diff" && len(args) == 7:
+		// GIT_EXTERNAL_DIFF: path old-file old-hex old-mode new-file
+		// new-hex new-mode.
+		fmt.Fprintf(stdout, "opml-git diff %s\n", args[0])
+		err = diff(args[1], args[4], stdout)
+	default:
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "opml-git:", err)
+		return 2
+	}
+	return status
+}
+
+// readDocument parses the OPML file at path. An empty file, such as git
+// passes for a missing merge base or a new file, is an empty document.
+func readDocument(path string) (*opml.OPML, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return &opml.OPML{Version: "2.0"}, nil
+	}
+	doc, err := opml.ImportFormat(bytes.NewReader(b), "opml")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return doc, nil
+}
+
+func writeDocument(path string, doc *opml.OPML) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, xml.Header); err != nil {
+		f.Close()
+		return err
+	}
+	r := opml.NewRenderer(f)
+	r.XMLEncoder.Indent("", "  ")
+	if err := r.Render(doc); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := io.WriteString(f, "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func merge(basePath, oursPath, theirsPath string, stderr io.Writer) (int, error) {
+	var docs [3]*opml.OPML
+	for i, path := range []string{basePath, oursPath, theirsPath} {
+		doc, err := readDocument(path)
+		if err != nil {
+			return 0, err
+		}
+		docs[i] = doc
+	}
+
+	merged, conflicts := opml.Merge3(docs[0], docs[1], docs[2], &opml.MergeOptions{MarkConflicts: true})
+	if err := writeDocument(oursPath, merged); err != nil {
+		return 0, err
+	}
+	for _, c := range conflicts {
+		fmt.Fprintln(stderr, "opml-git:", c)
+	}
+	if len(conflicts) > 0 {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func textconv(path string, w io.Writer) error {
+	doc, err := readDocument(path)
+	if err != nil {
+		return err
+	}
+	if doc.Title != "" {
+		if _, err := fmt.Fprintf(w, "# %s\n", doc.Title); err != nil {
+			return err
+		}
+	}
+	return doc.Walk(opml.PreOrder, func(n opml.Node) error {
+		_, err := fmt.Fprintf(w, "%s%s\n", strings.Repeat("  ", n.Depth), outlineLine(n.Outline))
+		return err
+	})
+}
+
+// outlineLine formats an outline as its text followed by the attributes
+// that tell outlines apart.
+func outlineLine(o *opml.Outline) string {
+	parts := []string{o.Text}
+	if o.IsComment {
+		parts[0] = "// " + o.Text
+	}
+	if o.Type != "" {
+		parts = append(parts, "["+o.Type+"]")
+	}
+	for _, attr := range []struct {
+		name  string
+		value *url.URL
+	}{
+		{"xmlUrl", o.XMLURL},
+		{"htmlUrl", o.HTMLURL},
+		{"url", o.URL},
+	} {
+		if attr.value != nil {
+			parts = append(parts, attr.name+"="+attr.value.String())
+		}
+	}
+	if len(o.Categories) > 0 {
+		parts = append(parts, "category="+strings.Join(o.Categories, ","))
+	}
+	if o.Language != "" {
+		parts = append(parts, "language="+o.Language)
+	}
+	return strings.Join(parts, " ")
+}
+
+func diff(oldPath, newPath string, w io.Writer) error {
+	old, err := readDocument(oldPath)
+	if err != nil {
+		return err
+	}
+	new, err := readDocument(newPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, opml.Compare(old, new, nil).String())
+	return err
+}
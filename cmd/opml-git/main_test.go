@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kaorimatz/go-opml"
+)
+
+const base = `<opml version="2.0"><head><title>Feeds</title></head><body>
+<outline text="Tech">
+  <outline text="Scripting News" type="rss" xmlUrl="http://www.scripting.com/rss.xml"/>
+</outline>
+<outline text="News">
+  <outline text="NYT" type="rss" xmlUrl="http://www.nytimes.com/rss.xml" language="en"/>
+</outline>
+</body></opml>`
+
+func writeFiles(t *testing.T, contents ...string) []string {
+	dir := t.TempDir()
+	var paths []string
+	for i, c := range contents {
+		path := filepath.Join(dir, string(rune('a'+i))+".opml")
+		if err := ioutil.WriteFile(path, []byte(c), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestMerge(t *testing.T) {
+	ours := strings.Replace(base, `<outline text="News">`, `<outline text="News"><outline text="CNET" type="rss" xmlUrl="http://news.com.com/rss.xml"/>`, 1)
+	theirs := strings.Replace(base, `language="en"`, `language="en-us"`, 1)
+	paths := writeFiles(t, base, ours, theirs)
+
+	var stdout, stderr bytes.Buffer
+	if status := run(append([]string{"merge"}, paths...), &stdout, &stderr); status != 0 {
+		t.Fatalf("Status mismatch\nexpected: %d\ngot: %d\n%s", 0, status, stderr.String())
+	}
+
+	var out bytes.Buffer
+	if status := run([]string{"textconv", paths[1]}, &out, &stderr); status != 0 {
+		t.Fatalf("Status mismatch\nexpected: %d\ngot: %d\n%s", 0, status, stderr.String())
+	}
+	want := `# Feeds
+Tech
+  Scripting News [rss] xmlUrl=http://www.scripting.com/rss.xml
+News
+  CNET [rss] xmlUrl=http://news.com.com/rss.xml
+  NYT [rss] xmlUrl=http://www.nytimes.com/rss.xml language=en-us
+`
+	if got := out.String(); got != want {
+		t.Errorf("Output mismatch\nexpected: %s\ngot: %s\n", want, got)
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	ours := strings.Replace(base, `language="en"`, `language="fr"`, 1)
+	theirs := strings.Replace(base, `language="en"`, `language="en-us"`, 1)
+	paths := writeFiles(t, base, ours, theirs)
+
+	var stdout, stderr bytes.Buffer
+	if status := run(append([]string{"merge"}, paths...), &stdout, &stderr); status != 1 {
+		t.Fatalf("Status mismatch\nexpected: %d\ngot: %d\n%s", 1, status, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "field conflict") {
+		t.Errorf("Stderr mismatch\nexpected: %s\ngot: %q\n", "field conflict", stderr.String())
+	}
+
+	b, err := ioutil.ReadFile(paths[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, err := opml.Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal("Failed to parse merged document:", err)
+	}
+	news := merged.Outlines[1].Outlines
+	if len(news) != 2 || news[0].Language != "fr" || !news[1].IsComment {
+		t.Errorf("News mismatch\nexpected: ours followed by a conflict marker\ngot: %+v\n", news)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	theirs := strings.Replace(base, `language="en"`, `language="en-us"`, 1)
+	paths := writeFiles(t, base, theirs)
+
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"diff", paths[0], paths[1]}, &stdout, &stderr); status != 0 {
+		t.Fatalf("Status mismatch\nexpected: %d\ngot: %d\n%s", 0, status, stderr.String())
+	}
+	want := `~ 1.0 "NYT" language: "en" -> "en-us"` + "\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("Output mismatch\nexpected: %q\ngot: %q\n", want, got)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"merge", "a"}, &stdout, &stderr); status != 2 {
+		t.Errorf("Status mismatch\nexpected: %d\ngot: %d\n", 2, status)
+	}
+	if !strings.HasPrefix(stderr.String(), "usage:") {
+		t.Errorf("Usage mismatch\nexpected: %s\ngot: %q\n", "usage: ...", stderr.String())
+	}
+}
+
+func TestTextconvCharset(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"textconv", "../../testdata/subscriptionList.opml"}, &stdout, &stderr); status != 0 {
+		t.Fatalf("Status mismatch\nexpected: %d\ngot: %d\n%s", 0, status, stderr.String())
+	}
+	if want := "# mySubscriptions.opml\n"; !strings.HasPrefix(stdout.String(), want) {
+		t.Errorf("Output mismatch\nexpected: %s...\ngot: %s\n", want, stdout.String())
+	}
+}
@@ -0,0 +1,280 @@
+package opml
+
+// A Cursor points at an outline of a document, or at the document root.
+// Cursors derived from one another share a parent index, so a cursor stays
+// on its outline when edits made through any of them move things around.
+// A cursor whose outline was removed is no longer valid.
+type Cursor struct {
+	tree    *cursorTree
+	outline *Outline
+}
+
+type cursorTree struct {
+	doc *OPML
+	// parents maps each outline to its parent; top-level outlines map to
+	// nil.
+	parents map[*Outline]*Outline
+}
+
+func (t *cursorTree) index() {
+	t.parents = map[*Outline]*Outline{}
+	var walk func(*Outline, []*Outline)
+	walk = func(parent *Outline, os []*Outline) {
+		for _, o := range os {
+			t.parents[o] = parent
+			walk(o, o.Outlines)
+		}
+	}
+	walk(nil, t.doc.Outlines)
+}
+
+func (t *cursorTree) children(parent *Outline) []*Outline {
+	if parent == nil {
+		return t.doc.Outlines
+	}
+	return parent.Outlines
+}
+
+func (t *cursorTree) setChildren(parent *Outline, os []*Outline) {
+	if parent == nil {
+		t.doc.Outlines = os
+	} else {
+		parent.Outlines = os
+	}
+}
+
+// position returns the parent of o and its index among the parent's
+// children. The index is rebuilt if the document was edited behind the
+// cursors' back.
+func (t *cursorTree) position(o *Outline) (*Outline, int, bool) {
+	for attempt := 0; attempt < 2; attempt++ {
+		if parent, ok := t.parents[o]; ok {
+			for i, c := range t.children(parent) {
+				if c == o {
+					return parent, i, true
+				}
+			}
+		}
+		t.index()
+	}
+	return nil, 0, false
+}
+
+func (t *cursorTree) cursor(o *Outline) *Cursor {
+	return &Cursor{tree: t, outline: o}
+}
+
+// NewCursor returns a cursor at the root of doc.
+func NewCursor(doc *OPML) *Cursor {
+	t := &cursorTree{doc: doc}
+	t.index()
+	return t.cursor(nil)
+}
+
+func (c *Cursor) Document() *OPML {
+	return c.tree.doc
+}
+
+// Outline returns the outline under the cursor, or nil at the root.
+func (c *Cursor) Outline() *Outline {
+	return c.outline
+}
+
+func (c *Cursor) IsRoot() bool {
+	return c.outline == nil
+}
+
+func (c *Cursor) Valid() bool {
+	if c.outline == nil {
+		return true
+	}
+	_, _, ok := c.tree.position(c.outline)
+	return ok
+}
+
+// Path returns the index path from the root, or nil if the cursor is no
+// longer valid. The root has an empty path.
+func (c *Cursor) Path() []int {
+	path := []int{}
+	for o := c.outline; o != nil; {
+		parent, i, ok := c.tree.position(o)
+		if !ok {
+			return nil
+		}
+		path = append([]int{i}, path...)
+		o = parent
+	}
+	return path
+}
+
+// Depth returns 0 for top-level outlines and -1 at the root.
+func (c *Cursor) Depth() int {
+	return len(c.Path()) - 1
+}
+
+// At returns a cursor at the outline addressed by path, relative to c.
+func (c *Cursor) At(path ...int) *Cursor {
+	o := c.outline
+	for _, i := range path {
+		children := c.tree.children(o)
+		if i < 0 || i >= len(children) {
+			return nil
+		}
+		o = children[i]
+	}
+	return c.tree.cursor(o)
+}
+
+// CursorFor returns a cursor sharing c's index at o, or nil if o is not in
+// the document.
+func (c *Cursor) CursorFor(o *Outline) *Cursor {
+	if _, _, ok := c.tree.position(o); !ok {
+		return nil
+	}
+	return c.tree.cursor(o)
+}
+
+func (c *Cursor) Parent() *Cursor {
+	if c.outline == nil {
+		return nil
+	}
+	parent, _, ok := c.tree.position(c.outline)
+	if !ok {
+		return nil
+	}
+	return c.tree.cursor(parent)
+}
+
+func (c *Cursor) FirstChild() *Cursor {
+	children := c.tree.children(c.outline)
+	if len(children) == 0 {
+		return nil
+	}
+	return c.tree.cursor(children[0])
+}
+
+func (c *Cursor) LastChild() *Cursor {
+	children := c.tree.children(c.outline)
+	if len(children) == 0 {
+		return nil
+	}
+	return c.tree.cursor(children[len(children)-1])
+}
+
+func (c *Cursor) sibling(offset int) *Cursor {
+	if c.outline == nil {
+		return nil
+	}
+	parent, i, ok := c.tree.position(c.outline)
+	if !ok {
+		return nil
+	}
+	siblings := c.tree.children(parent)
+	if i+offset < 0 || i+offset >= len(siblings) {
+		return nil
+	}
+	return c.tree.cursor(siblings[i+offset])
+}
+
+func (c *Cursor) NextSibling() *Cursor {
+	return c.sibling(1)
+}
+
+func (c *Cursor) PrevSibling() *Cursor {
+	return c.sibling(-1)
+}
+
+// preserveExpansion runs edit and then renumbers the document's
+// expansionState so the same outlines stay expanded.
+func (t *cursorTree) preserveExpansion(edit func()) {
+	if t.doc.ExpansionState == nil {
+		edit()
+		return
+	}
+	expanded := expandedOutlines(t.doc.Outlines, t.doc.ExpansionState)
+	edit()
+	t.doc.ExpansionState = expansionState(t.doc.Outlines, expanded)
+}
+
+func (t *cursorTree) insert(parent *Outline, i int, o *Outline) {
+	t.preserveExpansion(func() {
+		children := t.children(parent)
+		children = append(children, nil)
+		copy(children[i+1:], children[i:])
+		children[i] = o
+		t.setChildren(parent, children)
+	})
+
+	t.parents[o] = parent
+	for _, n := range collectNodes(o.Walk, PreOrder)[1:] {
+		t.parents[n.Outline] = n.Parent()
+	}
+}
+
+func (t *cursorTree) remove(o *Outline) bool {
+	parent, i, ok := t.position(o)
+	if !ok {
+		return false
+	}
+
+	t.preserveExpansion(func() {
+		children := t.children(parent)
+		t.setChildren(parent, append(children[:i:i], children[i+1:]...))
+	})
+
+	for _, n := range collectNodes(o.Walk, PreOrder) {
+		delete(t.parents, n.Outline)
+	}
+	return true
+}
+
+// InsertBefore inserts o as the previous sibling of the cursor's outline
+// and returns a cursor at o.
+func (c *Cursor) InsertBefore(o *Outline) *Cursor {
+	if c.outline == nil {
+		return nil
+	}
+	parent, i, ok := c.tree.position(c.outline)
+	if !ok {
+		return nil
+	}
+	c.tree.insert(parent, i, o)
+	return c.tree.cursor(o)
+}
+
+func (c *Cursor) InsertAfter(o *Outline) *Cursor {
+	if c.outline == nil {
+		return nil
+	}
+	parent, i, ok := c.tree.position(c.outline)
+	if !ok {
+		return nil
+	}
+	c.tree.insert(parent, i+1, o)
+	return c.tree.cursor(o)
+}
+
+func (c *Cursor) PrependChild(o *Outline) *Cursor {
+	if !c.Valid() {
+		return nil
+	}
+	c.tree.insert(c.outline, 0, o)
+	return c.tree.cursor(o)
+}
+
+func (c *Cursor) AppendChild(o *Outline) *Cursor {
+	if !c.Valid() {
+		return nil
+	}
+	c.tree.insert(c.outline, len(c.tree.children(c.outline)), o)
+	return c.tree.cursor(o)
+}
+
+// Remove detaches the cursor's outline and its subtree from the document.
+// Cursors at removed outlines become invalid.
+func (c *Cursor) Remove() bool {
+	if c.outline == nil {
+		return false
+	}
+	return c.tree.remove(c.outline)
+}
@@ -0,0 +1,103 @@
+package opml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newPlacesLived() *OPML {
+	return &OPML{
+		ExpansionState: []int{1, 2, 5},
+		Outlines: []*Outline{
+			{
+				Text: "Places I've lived",
+				Outlines: []*Outline{
+					{Text: "Boston", Outlines: []*Outline{{Text: "Cambridge"}, {Text: "West Newton"}}},
+					{Text: "Bay Area", Outlines: []*Outline{{Text: "Mountain View"}, {Text: "Los Gatos"}}},
+					{Text: "New Orleans", Outlines: []*Outline{{Text: "Uptown"}}},
+				},
+			},
+		},
+	}
+}
+
+func TestCursorNavigation(t *testing.T) {
+	doc := newPlacesLived()
+	root := NewCursor(doc)
+
+	c := root.FirstChild().FirstChild().NextSibling()
+	if c.Outline().Text != "Bay Area" {
+		t.Errorf("Outline mismatch\nexpected: %s\ngot: %s\n", "Bay Area", c.Outline().Text)
+	}
+	if want := []int{0, 1}; !reflect.DeepEqual(want, c.Path()) {
+		t.Errorf("Path mismatch\nexpected: %v\ngot: %v\n", want, c.Path())
+	}
+	if c.Depth() != 1 {
+		t.Errorf("Depth mismatch\nexpected: %d\ngot: %d\n", 1, c.Depth())
+	}
+	if c.PrevSibling().Outline().Text != "Boston" {
+		t.Errorf("Outline mismatch\nexpected: %s\ngot: %s\n", "Boston", c.PrevSibling().Outline().Text)
+	}
+	if c.LastChild().Outline().Text != "Los Gatos" {
+		t.Errorf("Outline mismatch\nexpected: %s\ngot: %s\n", "Los Gatos", c.LastChild().Outline().Text)
+	}
+	if c.Parent().Parent() == nil || !c.Parent().Parent().IsRoot() {
+		t.Error("Expected root cursor")
+	}
+	if root.Parent() != nil || root.NextSibling() != nil || c.LastChild().FirstChild() != nil {
+		t.Error("Expected nil cursor")
+	}
+	if root.At(0, 2, 0).Outline().Text != "Uptown" || root.At(0, 3) != nil {
+		t.Error("Unexpected cursor from path")
+	}
+}
+
+func TestCursorEdits(t *testing.T) {
+	doc := newPlacesLived()
+	root := NewCursor(doc)
+	bayArea := root.At(0, 1)
+	uptown := root.At(0, 2, 0)
+
+	florida := bayArea.InsertBefore(&Outline{Text: "Florida"})
+	if want := []int{0, 2}; !reflect.DeepEqual(want, bayArea.Path()) {
+		t.Errorf("Path mismatch\nexpected: %v\ngot: %v\n", want, bayArea.Path())
+	}
+	if want := []int{0, 1}; !reflect.DeepEqual(want, florida.Path()) {
+		t.Errorf("Path mismatch\nexpected: %v\ngot: %v\n", want, florida.Path())
+	}
+
+	// Cambridge, West Newton and Florida now come before Bay Area.
+	if want := []int{1, 2, 6}; !reflect.DeepEqual(want, doc.ExpansionState) {
+		t.Errorf("ExpansionState mismatch\nexpected: %v\ngot: %v\n", want, doc.ExpansionState)
+	}
+
+	root.At(0, 0).Remove()
+	if want := []int{1, 3}; !reflect.DeepEqual(want, doc.ExpansionState) {
+		t.Errorf("ExpansionState mismatch\nexpected: %v\ngot: %v\n", want, doc.ExpansionState)
+	}
+	if want := []int{0, 2, 0}; !reflect.DeepEqual(want, uptown.Path()) {
+		t.Errorf("Path mismatch\nexpected: %v\ngot: %v\n", want, uptown.Path())
+	}
+
+	miami := florida.AppendChild(&Outline{Text: "Miami"})
+	if miami.Parent().Outline() != florida.Outline() {
+		t.Error("Parent mismatch")
+	}
+
+	if !florida.Remove() || florida.Valid() || miami.Valid() || florida.Path() != nil {
+		t.Error("Expected removed cursors to be invalid")
+	}
+	if !uptown.Valid() {
+		t.Error("Expected cursor to stay valid")
+	}
+}
+
+func TestCursorAfterExternalEdit(t *testing.T) {
+	doc := newPlacesLived()
+	c := NewCursor(doc).At(0, 2)
+
+	doc.Outlines = append([]*Outline{{Text: "Elsewhere"}}, doc.Outlines...)
+	if want := []int{1, 2}; !reflect.DeepEqual(want, c.Path()) {
+		t.Errorf("Path mismatch\nexpected: %v\ngot: %v\n", want, c.Path())
+	}
+}
@@ -0,0 +1,211 @@
+package opml
+
+import (
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// A URLNormalizer maps URLs that lead to the same feed to the same key.
+type URLNormalizer func(u *url.URL) string
+
+var feedBurnerHosts = map[string]bool{
+	"feeds.feedburner.com":  true,
+	"feeds2.feedburner.com": true,
+	"feedproxy.google.com":  true,
+}
+
+// NormalizeURL ignores the scheme, a leading "www.", default ports, a
+// trailing slash, the fragment, utm_ query parameters and the order of the
+// others. FeedBurner URLs are keyed by feed name alone.
+func NormalizeURL(u *url.URL) string {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+
+	if feedBurnerHosts[host] || feedBurnerHosts["www."+host] {
+		name := strings.TrimPrefix(path, "/")
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[:i]
+		}
+		return "feedburner/" + strings.ToLower(name)
+	}
+
+	query := u.Query()
+	for name := range query {
+		if strings.HasPrefix(strings.ToLower(name), "utm_") {
+			delete(query, name)
+		}
+	}
+	key := host + path
+	if len(query) > 0 {
+		key += "?" + query.Encode()
+	}
+	return key
+}
+
+type DedupOptions struct {
+	// Normalize keys the XMLURL, or the HTMLURL of outlines without one.
+	// Outlines with the same key are duplicates. Defaults to NormalizeURL.
+	Normalize URLNormalizer
+	// Similarity, when above 0, also makes duplicates of outlines with a
+	// URL whose text or title are at least this similar, from 0 to 1.
+	Similarity float64
+}
+
+// A Duplicate reports the outlines folded into a survivor, the first of
+// them in document order.
+type Duplicate struct {
+	Survivor *Outline
+	Folded   []*Outline
+}
+
+// similarity compares the case-folded strings by edit distance, from 0
+// for nothing in common to 1 for equal.
+func similarity(a, b string) float64 {
+	a, b = strings.ToLower(strings.TrimSpace(a)), strings.ToLower(strings.TrimSpace(b))
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	longest := utf8.RuneCountInString(a)
+	if n := utf8.RuneCountInString(b); n > longest {
+		longest = n
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+func similarOutlines(a, b *Outline, threshold float64) bool {
+	return similarity(a.Text, b.Text) >= threshold ||
+		similarity(a.Title, b.Title) >= threshold
+}
+
+func mergeCategories(into []string, from []string) []string {
+	for _, c := range from {
+		found := false
+		for _, d := range into {
+			if c == d {
+				found = true
+				break
+			}
+		}
+		if !found {
+			into = append(into, c)
+		}
+	}
+	return into
+}
+
+func removeOutlines(os []*Outline, remove map[*Outline]bool) []*Outline {
+	kept := os[:0]
+	for _, o := range os {
+		if remove[o] {
+			continue
+		}
+		o.Outlines = removeOutlines(o.Outlines, remove)
+		kept = append(kept, o)
+	}
+	return kept
+}
+
+// Dedup folds duplicate feeds and links into the first of them. The
+// survivor gains the categories and children of its duplicates.
+func Dedup(doc *OPML, opts *DedupOptions) []Duplicate {
+	normalize := NormalizeURL
+	var threshold float64
+	if opts != nil {
+		if opts.Normalize != nil {
+			normalize = opts.Normalize
+		}
+		threshold = opts.Similarity
+	}
+
+	var duplicates []*Duplicate
+	byKey := map[string]*Duplicate{}
+	doc.Walk(PreOrder, func(n Node) error {
+		o := n.Outline
+		u := o.XMLURL
+		if u == nil {
+			u = o.HTMLURL
+		}
+		if u == nil {
+			return nil
+		}
+		key := normalize(u)
+
+		d, ok := byKey[key]
+		if !ok && threshold > 0 {
+			for _, s := range duplicates {
+				if similarOutlines(s.Survivor, o, threshold) {
+					d, ok = s, true
+					break
+				}
+			}
+		}
+		if !ok {
+			d = &Duplicate{Survivor: o}
+			duplicates = append(duplicates, d)
+		} else {
+			d.Folded = append(d.Folded, o)
+		}
+		if _, seen := byKey[key]; !seen {
+			byKey[key] = d
+		}
+		return nil
+	})
+
+	var folded []Duplicate
+	for _, d := range duplicates {
+		if len(d.Folded) > 0 {
+			folded = append(folded, *d)
+		}
+	}
+	if len(folded) == 0 {
+		return nil
+	}
+
+	editExpansion(doc, func(expanded map[*Outline]bool) (Edit, error) {
+		remove := map[*Outline]bool{}
+		for _, d := range folded {
+			for _, o := range d.Folded {
+				d.Survivor.Categories = mergeCategories(d.Survivor.Categories, o.Categories)
+				d.Survivor.Outlines = append(d.Survivor.Outlines, o.Outlines...)
+				remove[o] = true
+			}
+		}
+		doc.Outlines = removeOutlines(doc.Outlines, remove)
+		return nil, nil
+	})
+	return folded
+}
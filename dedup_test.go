@@ -0,0 +1,94 @@
+package opml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"http://example.com/feed/", "https://www.example.com/feed"},
+		{"http://example.com:80/feed?b=2&a=1", "https://example.com/feed?a=1&b=2&utm_source=x"},
+		{"http://feeds.feedburner.com/Example", "http://feedproxy.google.com/example/"},
+		{"http://example.com/feed#top", "http://EXAMPLE.com/feed"},
+	}
+	for _, test := range tests {
+		a, b := NormalizeURL(parseURL(test.a)), NormalizeURL(parseURL(test.b))
+		if a != b {
+			t.Errorf("Key mismatch for %s and %s\nexpected: %q\ngot: %q\n", test.a, test.b, a, b)
+		}
+	}
+
+	if a, b := NormalizeURL(parseURL("http://example.com/a")), NormalizeURL(parseURL("http://example.com/b")); a == b {
+		t.Errorf("Expected different keys\ngot: %q\n", a)
+	}
+}
+
+func dedupDocument() *OPML {
+	return &OPML{
+		ExpansionState: []int{1, 4},
+		Outlines: []*Outline{
+			{Text: "Tech", Outlines: []*Outline{
+				{Text: "Example", XMLURL: parseURL("http://example.com/feed/"), Categories: []string{"/tech"}},
+				{Text: "Other", XMLURL: parseURL("http://other.com/rss")},
+			}},
+			{Text: "News", Outlines: []*Outline{
+				{Text: "Example Blog", XMLURL: parseURL("https://example.com/feed?utm_source=rss"), Categories: []string{"/tech", "/news"}},
+				{Text: "other", Title: "Other", XMLURL: parseURL("http://feeds.feedburner.com/Other")},
+			}},
+		},
+	}
+}
+
+func TestDedup(t *testing.T) {
+	doc := dedupDocument()
+	survivor := doc.Outlines[0].Outlines[0]
+	duplicate := doc.Outlines[1].Outlines[0]
+
+	got := Dedup(doc, nil)
+	want := []Duplicate{{Survivor: survivor, Folded: []*Outline{duplicate}}}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Duplicates mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+
+	wantTexts := []interface{}{"Tech", []interface{}{"Example", "Other"}, "News", []interface{}{"other"}}
+	if texts := outlineTexts(doc.Outlines); !reflect.DeepEqual(wantTexts, texts) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", wantTexts, texts)
+	}
+	if c := []string{"/tech", "/news"}; !reflect.DeepEqual(c, survivor.Categories) {
+		t.Errorf("Categories mismatch\nexpected: %v\ngot: %v\n", c, survivor.Categories)
+	}
+	if state := []int{1, 4}; !reflect.DeepEqual(state, doc.ExpansionState) {
+		t.Errorf("ExpansionState mismatch\nexpected: %v\ngot: %v\n", state, doc.ExpansionState)
+	}
+}
+
+func TestDedupSimilarity(t *testing.T) {
+	doc := dedupDocument()
+	other := doc.Outlines[0].Outlines[1]
+	feedBurner := doc.Outlines[1].Outlines[1]
+
+	got := Dedup(doc, &DedupOptions{Similarity: 0.8})
+	want := Duplicate{Survivor: other, Folded: []*Outline{feedBurner}}
+	if len(got) != 2 || !reflect.DeepEqual(want, got[1]) {
+		t.Errorf("Duplicates mismatch\nexpected: [_ %v]\ngot: %v\n", want, got)
+	}
+	wantTexts := []interface{}{"Tech", []interface{}{"Example", "Other"}, "News"}
+	if texts := outlineTexts(doc.Outlines); !reflect.DeepEqual(wantTexts, texts) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", wantTexts, texts)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	if s := similarity("Scripting News", "scripting news"); s != 1 {
+		t.Errorf("Similarity mismatch\nexpected: %v\ngot: %v\n", 1, s)
+	}
+	if s := similarity("kitten", "sitting"); s < 0.57 || s > 0.58 {
+		t.Errorf("Similarity mismatch\nexpected: %v\ngot: %v\n", "4/7", s)
+	}
+	if s := similarity("", ""); s != 0 {
+		t.Errorf("Similarity mismatch\nexpected: %v\ngot: %v\n", 0, s)
+	}
+}
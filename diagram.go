@@ -0,0 +1,145 @@
+package opml
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+type LabelSource int
+
+const (
+	LabelText LabelSource = iota
+	LabelTitle
+)
+
+type HTMLMode int
+
+const (
+	// HTMLRaw leaves markup in the label untouched.
+	HTMLRaw HTMLMode = iota
+	// HTMLEscape replaces markup characters with entities.
+	HTMLEscape
+	// HTMLStrip removes tags and decodes entities.
+	HTMLStrip
+)
+
+type DiagramOptions struct {
+	// MaxDepth limits the number of outline levels rendered. Zero means
+	// no limit.
+	MaxDepth int
+	Label    LabelSource
+	HTML     HTMLMode
+	// Cluster groups each outline with children into a subgraph. It is
+	// ignored by formats without clusters.
+	Cluster bool
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+func (opts *DiagramOptions) label(o *Outline) string {
+	l := o.Text
+	if opts.Label == LabelTitle && o.Title != "" {
+		l = o.Title
+	}
+
+	switch opts.HTML {
+	case HTMLEscape:
+		l = html.EscapeString(l)
+	case HTMLStrip:
+		l = html.UnescapeString(htmlTagPattern.ReplaceAllString(l, ""))
+	}
+	return l
+}
+
+func (opts *DiagramOptions) inDepth(depth int) bool {
+	return opts.MaxDepth <= 0 || depth <= opts.MaxDepth
+}
+
+type dotWriter struct {
+	w    *bufio.Writer
+	opts *DiagramOptions
+	n    int
+}
+
+func (dw *dotWriter) outlines(os []*Outline, parent string, depth int, indent string) {
+	if !dw.opts.inDepth(depth) {
+		return
+	}
+	for _, o := range os {
+		id := fmt.Sprintf("n%d", dw.n)
+		dw.n++
+
+		children := len(o.Outlines) > 0 && dw.opts.inDepth(depth+1)
+		inner := indent
+		if dw.opts.Cluster && children {
+			fmt.Fprintf(dw.w, "%ssubgraph cluster_%s {\n", indent, id)
+			fmt.Fprintf(dw.w, "%s\tlabel=%s;\n", indent, dotQuote(dw.opts.label(o)))
+			inner += "\t"
+		}
+
+		fmt.Fprintf(dw.w, "%s%s [label=%s];\n", inner, id, dotQuote(dw.opts.label(o)))
+		if parent != "" {
+			fmt.Fprintf(dw.w, "%s%s -> %s;\n", inner, parent, id)
+		}
+		dw.outlines(o.Outlines, id, depth+1, inner)
+
+		if dw.opts.Cluster && children {
+			fmt.Fprintf(dw.w, "%s}\n", indent)
+		}
+	}
+}
+
+var dotReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func dotQuote(s string) string {
+	return `"` + dotReplacer.Replace(s) + `"`
+}
+
+func RenderDOT(w io.Writer, opml *OPML, opts *DiagramOptions) error {
+	if opts == nil {
+		opts = &DiagramOptions{}
+	}
+
+	dw := &dotWriter{w: bufio.NewWriter(w), opts: opts}
+	fmt.Fprintf(dw.w, "digraph %s {\n", dotQuote(opml.Title))
+	dw.outlines(opml.Outlines, "", 1, "\t")
+	fmt.Fprintln(dw.w, "}")
+	return dw.w.Flush()
+}
+
+var mermaidReplacer = strings.NewReplacer(`"`, "#quot;", "\r\n", " ", "\n", " ", "\r", " ")
+
+func mermaidQuote(s string) string {
+	return `"` + mermaidReplacer.Replace(s) + `"`
+}
+
+func renderMermaidOutlines(w *bufio.Writer, os []*Outline, opts *DiagramOptions, depth int, n *int) {
+	if !opts.inDepth(depth) {
+		return
+	}
+	indent := strings.Repeat("  ", depth+1)
+	for _, o := range os {
+		fmt.Fprintf(w, "%sn%d[%s]\n", indent, *n, mermaidQuote(opts.label(o)))
+		*n++
+		renderMermaidOutlines(w, o.Outlines, opts, depth+1, n)
+	}
+}
+
+// RenderMermaid writes a Mermaid mindmap. Mermaid requires a single root,
+// so the document title is used as the root node.
+func RenderMermaid(w io.Writer, opml *OPML, opts *DiagramOptions) error {
+	if opts == nil {
+		opts = &DiagramOptions{}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "mindmap")
+	fmt.Fprintf(bw, "  root((%s))\n", mermaidQuote(opml.Title))
+	n := 0
+	renderMermaidOutlines(bw, opml.Outlines, opts, 1, &n)
+	return bw.Flush()
+}
@@ -0,0 +1,88 @@
+package opml
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+var diagramOPML = &OPML{
+	Title: "Diagram",
+	Outlines: []*Outline{
+		{
+			Text:  `<b>Feeds</b> & "news"`,
+			Title: "Feeds",
+			Outlines: []*Outline{
+				{Text: "Scripting News"},
+				{
+					Text:     "Nested",
+					Outlines: []*Outline{{Text: "Deep"}},
+				},
+			},
+		},
+		{Text: "Loose"},
+	},
+}
+
+func TestRenderDOT(t *testing.T) {
+	want := `digraph "Diagram" {
+	n0 [label="<b>Feeds</b> & \"news\""];
+	n1 [label="Scripting News"];
+	n0 -> n1;
+	n2 [label="Nested"];
+	n0 -> n2;
+	n3 [label="Deep"];
+	n2 -> n3;
+	n4 [label="Loose"];
+}
+`
+	testRenderDiagram(t, RenderDOT, nil, want)
+}
+
+func TestRenderDOTWithOptions(t *testing.T) {
+	want := `digraph "Diagram" {
+	subgraph cluster_n0 {
+		label="Feeds & \"news\"";
+		n0 [label="Feeds & \"news\""];
+		n1 [label="Scripting News"];
+		n0 -> n1;
+		n2 [label="Nested"];
+		n0 -> n2;
+	}
+	n3 [label="Loose"];
+}
+`
+	testRenderDiagram(t, RenderDOT, &DiagramOptions{MaxDepth: 2, HTML: HTMLStrip, Cluster: true}, want)
+}
+
+func TestRenderMermaid(t *testing.T) {
+	want := `mindmap
+  root(("Diagram"))
+    n0["&lt;b&gt;Feeds&lt;/b&gt; &amp; &#34;news&#34;"]
+      n1["Scripting News"]
+      n2["Nested"]
+        n3["Deep"]
+    n4["Loose"]
+`
+	testRenderDiagram(t, RenderMermaid, &DiagramOptions{HTML: HTMLEscape}, want)
+}
+
+func TestRenderMermaidWithTitleLabels(t *testing.T) {
+	want := `mindmap
+  root(("Diagram"))
+    n0["Feeds"]
+    n1["Loose"]
+`
+	testRenderDiagram(t, RenderMermaid, &DiagramOptions{MaxDepth: 1, Label: LabelTitle}, want)
+}
+
+func testRenderDiagram(t *testing.T, render func(io.Writer, *OPML, *DiagramOptions) error, opts *DiagramOptions, want string) {
+	var buf bytes.Buffer
+	if err := render(&buf, diagramOPML, opts); err != nil {
+		t.Error("Failed to render diagram:", err)
+	}
+
+	if got := buf.String(); got != want {
+		t.Errorf("Diagram mismatch\nexpected: %s\ngot: %s\n", want, got)
+	}
+}
@@ -0,0 +1,395 @@
+package opml
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An IdentityFunc returns the key used to match an outline across two
+// documents, or "" if it cannot identify the outline.
+type IdentityFunc func(n Node) string
+
+func IdentityXMLURL(n Node) string {
+	if n.Outline.XMLURL == nil {
+		return ""
+	}
+	return "xmlUrl:" + n.Outline.XMLURL.String()
+}
+
+func IdentityURL(n Node) string {
+	if n.Outline.URL == nil {
+		return ""
+	}
+	return "url:" + n.Outline.URL.String()
+}
+
+// IdentityTextPath identifies an outline by its text and the text of its
+// ancestors.
+func IdentityTextPath(n Node) string {
+	texts := make([]string, 0, len(n.Ancestors)+1)
+	for _, a := range n.Ancestors {
+		texts = append(texts, strconv.Quote(a.Text))
+	}
+	texts = append(texts, strconv.Quote(n.Outline.Text))
+	return "path:" + strings.Join(texts, "/")
+}
+
+// Identities returns an IdentityFunc that uses the first non-empty key
+// from fns.
+func Identities(fns ...IdentityFunc) IdentityFunc {
+	return func(n Node) string {
+		for _, fn := range fns {
+			if id := fn(n); id != "" {
+				return id
+			}
+		}
+		return ""
+	}
+}
+
+var DefaultIdentity = Identities(IdentityXMLURL, IdentityURL, IdentityTextPath)
+
+type DiffOptions struct {
+	// Identity matches outlines between the documents. Outlines it cannot
+	// identify fall back to IdentityTextPath. Defaults to DefaultIdentity.
+	Identity IdentityFunc
+}
+
+type ChangeKind string
+
+const (
+	Added    ChangeKind = "added"
+	Removed  ChangeKind = "removed"
+	Moved    ChangeKind = "moved"
+	Renamed  ChangeKind = "renamed"
+	Modified ChangeKind = "modified"
+)
+
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// An OutlineChange describes one change to an outline. An outline that was
+// moved and edited is reported once for each kind of change. An outline is
+// moved when its parent changes or when it changes places among the
+// siblings it kept.
+type OutlineChange struct {
+	Kind ChangeKind `json:"kind"`
+	ID   string     `json:"id"`
+	// OldPath and NewPath are index paths in the old and new documents.
+	OldPath []int `json:"oldPath,omitempty"`
+	NewPath []int `json:"newPath,omitempty"`
+	// OldParent and NewParent are the identities of the parents in the old
+	// and new documents, empty for the top level.
+	OldParent string `json:"oldParent,omitempty"`
+	NewParent string `json:"newParent,omitempty"`
+	// After is the identity of the previous sibling in the new document,
+	// empty for a first child.
+	After  string        `json:"after,omitempty"`
+	Fields []FieldChange `json:"fields,omitempty"`
+	Old    *Outline      `json:"-"`
+	New    *Outline      `json:"-"`
+}
+
+type Diff struct {
+	Head     []FieldChange   `json:"head,omitempty"`
+	Outlines []OutlineChange `json:"outlines,omitempty"`
+}
+
+func (d *Diff) Empty() bool {
+	return len(d.Head) == 0 && len(d.Outlines) == 0
+}
+
+type diffNode struct {
+	Node
+	id       string
+	parentID string
+	prevID   string
+}
+
+// identify keys every outline of doc. Outlines sharing a key are told
+// apart by the order in which they occur.
+func identify(doc *OPML, identity IdentityFunc) ([]*diffNode, map[string]*diffNode) {
+	var nodes []*diffNode
+	byID := map[string]*diffNode{}
+	ids := map[*Outline]string{}
+	seen := map[string]int{}
+
+	doc.Walk(PreOrder, func(n Node) error {
+		id := identity(n)
+		if id == "" {
+			id = IdentityTextPath(n)
+		}
+		if seen[id]++; seen[id] > 1 {
+			id += "#" + strconv.Itoa(seen[id])
+		}
+		ids[n.Outline] = id
+
+		dn := &diffNode{Node: n, id: id}
+		if p := n.Parent(); p != nil {
+			dn.parentID = ids[p]
+		}
+		if i := n.Path[len(n.Path)-1]; i > 0 {
+			siblings := doc.Outlines
+			if p := n.Parent(); p != nil {
+				siblings = p.Outlines
+			}
+			dn.prevID = ids[siblings[i-1]]
+		}
+		nodes = append(nodes, dn)
+		byID[id] = dn
+		return nil
+	})
+	return nodes, byID
+}
+
+// reorderedNodes returns the identities of the outlines that changed
+// places among the siblings kept under the same parent: those outside the
+// longest run of siblings left in the same order.
+func reorderedNodes(oldNodes, newNodes []*diffNode, oldByID, newByID map[string]*diffNode) map[string]bool {
+	kept := func(n *diffNode, other map[string]*diffNode) bool {
+		o, ok := other[n.id]
+		return ok && o.parentID == n.parentID
+	}
+
+	oldIndex := map[string]int{}
+	count := map[string]int{}
+	for _, n := range oldNodes {
+		if kept(n, newByID) {
+			oldIndex[n.id] = count[n.parentID]
+			count[n.parentID]++
+		}
+	}
+
+	siblings := map[string][]*diffNode{}
+	for _, n := range newNodes {
+		if kept(n, oldByID) {
+			siblings[n.parentID] = append(siblings[n.parentID], n)
+		}
+	}
+
+	reordered := map[string]bool{}
+	for _, nodes := range siblings {
+		indexes := make([]int, len(nodes))
+		for i, n := range nodes {
+			indexes[i] = oldIndex[n.id]
+		}
+		inOrder := longestIncreasing(indexes)
+		for i, n := range nodes {
+			if !inOrder[i] {
+				reordered[n.id] = true
+			}
+		}
+	}
+	return reordered
+}
+
+// longestIncreasing marks the elements of a longest increasing
+// subsequence of a.
+func longestIncreasing(a []int) []bool {
+	var tails []int
+	prev := make([]int, len(a))
+	for i, x := range a {
+		j := sort.Search(len(tails), func(k int) bool { return a[tails[k]] >= x })
+		prev[i] = -1
+		if j > 0 {
+			prev[i] = tails[j-1]
+		}
+		if j == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[j] = i
+		}
+	}
+
+	marked := make([]bool, len(a))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			marked[i] = true
+		}
+	}
+	return marked
+}
+
+var outlineAttrNames = []string{
+	"text", "type", "isComment", "isBreakpoint", "created", "category",
+	"xmlUrl", "description", "htmlUrl", "language", "title", "version",
+	"url",
+}
+
+func outlineAttrString(o *Outline, name string) string {
+	values, _ := outlineAttr(o, strings.ToLower(name))
+	return strings.Join(values, ",")
+}
+
+func compareFields(names []string, old, new func(string) string) []FieldChange {
+	var changes []FieldChange
+	for _, name := range names {
+		if o, n := old(name), new(name); o != n {
+			changes = append(changes, FieldChange{Field: name, Old: o, New: n})
+		}
+	}
+	return changes
+}
+
+var headFieldNames = []string{
+	"version", "title", "dateCreated", "dateModified", "ownerName",
+	"ownerEmail", "ownerId", "docs", "expansionState", "vertScrollState",
+	"windowTop", "windowLeft", "windowBottom", "windowRight",
+}
+
+func headField(o *OPML, name string) string {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC1123)
+	}
+	switch name {
+	case "version":
+		return o.Version
+	case "title":
+		return o.Title
+	case "dateCreated":
+		return formatTime(o.DateCreated)
+	case "dateModified":
+		return formatTime(o.DateModified)
+	case "ownerName":
+		return o.OwnerName
+	case "ownerEmail":
+		return o.OwnerEmail
+	case "ownerId":
+		v, _ := urlAttr(o.OwnerID)
+		return v[0]
+	case "docs":
+		v, _ := urlAttr(o.Docs)
+		return v[0]
+	case "expansionState":
+		states := make([]string, len(o.ExpansionState))
+		for i, n := range o.ExpansionState {
+			states[i] = strconv.Itoa(n)
+		}
+		return strings.Join(states, ",")
+	case "vertScrollState":
+		return strconv.Itoa(o.VertScrollState)
+	case "windowTop":
+		return strconv.Itoa(o.WindowTop)
+	case "windowLeft":
+		return strconv.Itoa(o.WindowLeft)
+	case "windowBottom":
+		return strconv.Itoa(o.WindowBottom)
+	case "windowRight":
+		return strconv.Itoa(o.WindowRight)
+	}
+	return ""
+}
+
+// Compare reports the changes that turn old into new.
+//
+// Outlines are matched by identity, so with DefaultIdentity a folder, which
+// has no URL, is identified by its text path: renaming a folder is reported
+// as removing it and adding it under the new name, along with its children.
+// Renames are only detected for outlines with a URL.
+func Compare(old, new *OPML, opts *DiffOptions) *Diff {
+	identity := DefaultIdentity
+	if opts != nil && opts.Identity != nil {
+		identity = opts.Identity
+	}
+
+	d := &Diff{}
+	d.Head = compareFields(headFieldNames,
+		func(name string) string { return headField(old, name) },
+		func(name string) string { return headField(new, name) })
+
+	oldNodes, oldByID := identify(old, identity)
+	newNodes, newByID := identify(new, identity)
+	reordered := reorderedNodes(oldNodes, newNodes, oldByID, newByID)
+
+	for _, on := range oldNodes {
+		if _, ok := newByID[on.id]; !ok {
+			d.Outlines = append(d.Outlines, OutlineChange{Kind: Removed, ID: on.id, OldPath: on.Path, OldParent: on.parentID, Old: on.Outline})
+		}
+	}
+
+	for _, nn := range newNodes {
+		on, ok := oldByID[nn.id]
+		if !ok {
+			d.Outlines = append(d.Outlines, OutlineChange{Kind: Added, ID: nn.id, NewPath: nn.Path, NewParent: nn.parentID, After: nn.prevID, New: nn.Outline})
+			continue
+		}
+
+		change := OutlineChange{
+			ID:        nn.id,
+			OldPath:   on.Path,
+			NewPath:   nn.Path,
+			OldParent: on.parentID,
+			NewParent: nn.parentID,
+			After:     nn.prevID,
+			Old:       on.Outline,
+			New:       nn.Outline,
+		}
+		if on.parentID != nn.parentID || reordered[nn.id] {
+			moved := change
+			moved.Kind = Moved
+			d.Outlines = append(d.Outlines, moved)
+		}
+
+		fields := compareFields(outlineAttrNames,
+			func(name string) string { return outlineAttrString(on.Outline, name) },
+			func(name string) string { return outlineAttrString(nn.Outline, name) })
+		if len(fields) > 0 && fields[0].Field == "text" {
+			renamed := change
+			renamed.Kind = Renamed
+			renamed.Fields = fields[:1]
+			d.Outlines = append(d.Outlines, renamed)
+			fields = fields[1:]
+		}
+		if len(fields) > 0 {
+			modified := change
+			modified.Kind = Modified
+			modified.Fields = fields
+			d.Outlines = append(d.Outlines, modified)
+		}
+	}
+	return d
+}
+
+func formatPath(path []int) string {
+	parts := make([]string, len(path))
+	for i, n := range path {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+func outlineLabel(o *Outline) string {
+	return strconv.Quote(o.Text)
+}
+
+// String formats the diff as one line per change.
+func (d *Diff) String() string {
+	var b strings.Builder
+	for _, f := range d.Head {
+		fmt.Fprintf(&b, "~ head %s: %q -> %q\n", f.Field, f.Old, f.New)
+	}
+	for _, c := range d.Outlines {
+		switch c.Kind {
+		case Added:
+			fmt.Fprintf(&b, "+ %s %s\n", formatPath(c.NewPath), outlineLabel(c.New))
+		case Removed:
+			fmt.Fprintf(&b, "- %s %s\n", formatPath(c.OldPath), outlineLabel(c.Old))
+		case Moved:
+			fmt.Fprintf(&b, "> %s %s moved to %s\n", formatPath(c.OldPath), outlineLabel(c.New), formatPath(c.NewPath))
+		case Renamed, Modified:
+			for _, f := range c.Fields {
+				fmt.Fprintf(&b, "~ %s %s %s: %q -> %q\n", formatPath(c.NewPath), outlineLabel(c.Old), f.Field, f.Old, f.New)
+			}
+		}
+	}
+	return b.String()
+}
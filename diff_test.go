@@ -0,0 +1,135 @@
+package opml
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func diffDocuments() (*OPML, *OPML) {
+	old := &OPML{
+		Title: "Subscriptions",
+		Outlines: []*Outline{
+			{
+				Text: "Tech",
+				Outlines: []*Outline{
+					{Text: "Scripting News", Type: "rss", XMLURL: parseURL("http://www.scripting.com/rss.xml")},
+					{Text: "Wired", Type: "rss", XMLURL: parseURL("http://www.wired.com/rss.xml")},
+				},
+			},
+			{
+				Text: "News",
+				Outlines: []*Outline{
+					{Text: "NYT", Type: "rss", XMLURL: parseURL("http://www.nytimes.com/rss.xml"), Language: "en"},
+				},
+			},
+		},
+	}
+	new := &OPML{
+		Title: "My subscriptions",
+		Outlines: []*Outline{
+			{
+				Text: "Tech",
+				Outlines: []*Outline{
+					{Text: "Scripting News!", Type: "rss", XMLURL: parseURL("http://www.scripting.com/rss.xml")},
+					{Text: "NYT", Type: "rss", XMLURL: parseURL("http://www.nytimes.com/rss.xml"), Language: "en-us"},
+				},
+			},
+			{
+				Text: "News",
+				Outlines: []*Outline{
+					{Text: "CNET", Type: "rss", XMLURL: parseURL("http://news.com.com/rss.xml")},
+				},
+			},
+		},
+	}
+	return old, new
+}
+
+func TestCompare(t *testing.T) {
+	old, new := diffDocuments()
+	d := Compare(old, new, nil)
+
+	want := `~ head title: "Subscriptions" -> "My subscriptions"
+- 0.1 "Wired"
+~ 0.0 "Scripting News" text: "Scripting News" -> "Scripting News!"
+> 1.0 "NYT" moved to 0.1
+~ 0.1 "NYT" language: "en" -> "en-us"
++ 1.0 "CNET"
+`
+	if got := d.String(); got != want {
+		t.Errorf("Diff mismatch\nexpected: %s\ngot: %s\n", want, got)
+	}
+
+	var kinds []ChangeKind
+	for _, c := range d.Outlines {
+		kinds = append(kinds, c.Kind)
+	}
+	if want := []ChangeKind{Removed, Renamed, Moved, Modified, Added}; !reflect.DeepEqual(want, kinds) {
+		t.Errorf("Kinds mismatch\nexpected: %v\ngot: %v\n", want, kinds)
+	}
+	if d.Outlines[2].OldParent != `path:"News"` || d.Outlines[2].NewParent != `path:"Tech"` {
+		t.Errorf("Parents mismatch\ngot: %q -> %q\n", d.Outlines[2].OldParent, d.Outlines[2].NewParent)
+	}
+}
+
+func TestCompareTextPath(t *testing.T) {
+	old, new := diffDocuments()
+	d := Compare(old, new, &DiffOptions{Identity: IdentityTextPath})
+
+	var kinds []ChangeKind
+	for _, c := range d.Outlines {
+		kinds = append(kinds, c.Kind)
+	}
+	want := []ChangeKind{Removed, Removed, Removed, Added, Added, Added}
+	if !reflect.DeepEqual(want, kinds) {
+		t.Errorf("Kinds mismatch\nexpected: %v\ngot: %v\n", want, kinds)
+	}
+}
+
+func TestCompareReorder(t *testing.T) {
+	feed := func(text string) *Outline {
+		return &Outline{Text: text, XMLURL: parseURL("http://example.com/" + text)}
+	}
+	old := &OPML{Outlines: []*Outline{feed("A"), feed("B"), feed("C"), feed("D")}}
+	new := &OPML{Outlines: []*Outline{feed("C"), feed("A"), feed("B"), feed("E")}}
+
+	want := `- 3 "D"
+> 2 "C" moved to 0
++ 3 "E"
+`
+	if got := Compare(old, new, nil).String(); got != want {
+		t.Errorf("Diff mismatch\nexpected: %s\ngot: %s\n", want, got)
+	}
+
+	// Removing and adding siblings does not move the others.
+	new = &OPML{Outlines: []*Outline{feed("E"), feed("A"), feed("C"), feed("D")}}
+	want = `- 1 "B"
++ 0 "E"
+`
+	if got := Compare(old, new, nil).String(); got != want {
+		t.Errorf("Diff mismatch\nexpected: %s\ngot: %s\n", want, got)
+	}
+}
+
+func TestCompareEqual(t *testing.T) {
+	if d := Compare(states, states, nil); !d.Empty() {
+		t.Errorf("Expected empty diff, got:\n%s", d)
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	old, new := diffDocuments()
+	b, err := json.Marshal(Compare(old, new, nil))
+	if err != nil {
+		t.Fatal("Failed to marshal diff:", err)
+	}
+
+	var got Diff
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal("Failed to unmarshal diff:", err)
+	}
+	if len(got.Head) != 1 || len(got.Outlines) != 5 || got.Outlines[4].ID != "xmlUrl:http://news.com.com/rss.xml" {
+		t.Errorf("Unexpected JSON: %s", b)
+	}
+}
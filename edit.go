@@ -0,0 +1,328 @@
+package opml
+
+import (
+	"errors"
+)
+
+// An Edit is a structural change to a document, addressed by index paths.
+// Apply makes the change, renumbering expansionState so the same outlines
+// stay expanded, and returns the edit that undoes it.
+type Edit interface {
+	Apply(doc *OPML) (inverse Edit, err error)
+}
+
+var (
+	ErrInvalidPath = errors.New("opml: invalid outline path")
+	ErrInvalidEdit = errors.New("opml: edit not possible at this position")
+)
+
+// childrenAt returns the children slice of the outline at path, or of the
+// document when path is empty.
+func childrenAt(doc *OPML, path []int) (*[]*Outline, bool) {
+	children := &doc.Outlines
+	for _, i := range path {
+		if i < 0 || i >= len(*children) {
+			return nil, false
+		}
+		children = &(*children)[i].Outlines
+	}
+	return children, true
+}
+
+func outlineAt(doc *OPML, path []int) (*Outline, bool) {
+	if len(path) == 0 {
+		return nil, false
+	}
+	siblings, ok := childrenAt(doc, path[:len(path)-1])
+	if !ok {
+		return nil, false
+	}
+	i := path[len(path)-1]
+	if i < 0 || i >= len(*siblings) {
+		return nil, false
+	}
+	return (*siblings)[i], true
+}
+
+func (o *OPML) OutlineAt(path ...int) *Outline {
+	outline, _ := outlineAt(o, path)
+	return outline
+}
+
+// editExpansion runs edit with the set of expanded outlines, which the edit
+// may change, and renumbers expansionState afterwards.
+func editExpansion(doc *OPML, edit func(expanded map[*Outline]bool) (Edit, error)) (Edit, error) {
+	if doc.ExpansionState == nil {
+		return edit(map[*Outline]bool{})
+	}
+	expanded := expandedOutlines(doc.Outlines, doc.ExpansionState)
+	inverse, err := edit(expanded)
+	if err == nil {
+		doc.ExpansionState = expansionState(doc.Outlines, expanded)
+	}
+	return inverse, err
+}
+
+func copyPath(path []int, more ...int) []int {
+	return append(append([]int{}, path...), more...)
+}
+
+// Move detaches the outline at Path and inserts it as child Index of the
+// outline at Parent. Parent and Index address the document as it is after
+// the outline has been detached; an empty Parent means the top level.
+type Move struct {
+	Path   []int
+	Parent []int
+	Index  int
+}
+
+func (m *Move) Apply(doc *OPML) (Edit, error) {
+	return editExpansion(doc, func(expanded map[*Outline]bool) (Edit, error) {
+		o, ok := outlineAt(doc, m.Path)
+		if !ok {
+			return nil, ErrInvalidPath
+		}
+		from := m.Path[:len(m.Path)-1]
+		i := m.Path[len(m.Path)-1]
+		siblings, _ := childrenAt(doc, from)
+
+		detached := append((*siblings)[:i:i], (*siblings)[i+1:]...)
+		*siblings = detached
+		target, ok := childrenAt(doc, m.Parent)
+		if !ok || m.Index < 0 || m.Index > len(*target) {
+			*siblings = append(detached[:i:i], append([]*Outline{o}, detached[i:]...)...)
+			return nil, ErrInvalidPath
+		}
+		*target = append((*target)[:m.Index:m.Index], append([]*Outline{o}, (*target)[m.Index:]...)...)
+
+		return &Move{Path: copyPath(m.Parent, m.Index), Parent: copyPath(from), Index: i}, nil
+	})
+}
+
+func equalPath(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func splitPath(path []int) ([]int, int, bool) {
+	if len(path) == 0 {
+		return nil, 0, false
+	}
+	return path[:len(path)-1], path[len(path)-1], true
+}
+
+// Indent makes the outline at Path the last child of its previous sibling.
+type Indent struct {
+	Path []int
+}
+
+func (e *Indent) Apply(doc *OPML) (Edit, error) {
+	parent, i, ok := splitPath(e.Path)
+	if !ok {
+		return nil, ErrInvalidPath
+	}
+	if i == 0 {
+		return nil, ErrInvalidEdit
+	}
+	prev, ok := outlineAt(doc, copyPath(parent, i-1))
+	if !ok {
+		return nil, ErrInvalidPath
+	}
+	return (&Move{Path: e.Path, Parent: copyPath(parent, i-1), Index: len(prev.Outlines)}).Apply(doc)
+}
+
+// Outdent makes the outline at Path the next sibling of its parent.
+type Outdent struct {
+	Path []int
+}
+
+func (e *Outdent) Apply(doc *OPML) (Edit, error) {
+	if len(e.Path) < 2 {
+		return nil, ErrInvalidEdit
+	}
+	grandparent, parent, _ := splitPath(e.Path[:len(e.Path)-1])
+	return (&Move{Path: e.Path, Parent: copyPath(grandparent), Index: parent + 1}).Apply(doc)
+}
+
+// MoveUp swaps the outline at Path with its previous sibling.
+type MoveUp struct {
+	Path []int
+}
+
+func (e *MoveUp) Apply(doc *OPML) (Edit, error) {
+	parent, i, ok := splitPath(e.Path)
+	if !ok {
+		return nil, ErrInvalidPath
+	}
+	if i == 0 {
+		return nil, ErrInvalidEdit
+	}
+	return (&Move{Path: e.Path, Parent: copyPath(parent), Index: i - 1}).Apply(doc)
+}
+
+// MoveDown swaps the outline at Path with its next sibling.
+type MoveDown struct {
+	Path []int
+}
+
+func (e *MoveDown) Apply(doc *OPML) (Edit, error) {
+	parent, i, ok := splitPath(e.Path)
+	if !ok {
+		return nil, ErrInvalidPath
+	}
+	siblings, ok := childrenAt(doc, parent)
+	if !ok || i >= len(*siblings) {
+		return nil, ErrInvalidPath
+	}
+	if i == len(*siblings)-1 {
+		return nil, ErrInvalidEdit
+	}
+	return (&Move{Path: e.Path, Parent: copyPath(parent), Index: i + 1}).Apply(doc)
+}
+
+// Split cuts the text of the outline at Path at byte Offset. The remainder
+// goes to a new next sibling, along with the last Children children. The
+// new outline copies the attributes of Template when it is set, and is
+// expanded if Expanded is set.
+type Split struct {
+	Path     []int
+	Offset   int
+	Children int
+	Template *Outline
+	Expanded bool
+}
+
+func (e *Split) Apply(doc *OPML) (Edit, error) {
+	return editExpansion(doc, func(expanded map[*Outline]bool) (Edit, error) {
+		o, ok := outlineAt(doc, e.Path)
+		if !ok {
+			return nil, ErrInvalidPath
+		}
+		if e.Offset < 0 || e.Offset > len(o.Text) || e.Children < 0 || e.Children > len(o.Outlines) {
+			return nil, ErrInvalidEdit
+		}
+
+		n := &Outline{}
+		if e.Template != nil {
+			*n = *e.Template
+		}
+		n.Text = o.Text[e.Offset:]
+		n.Outlines = nil
+		if e.Children > 0 {
+			keep := len(o.Outlines) - e.Children
+			n.Outlines = append([]*Outline{}, o.Outlines[keep:]...)
+			o.Outlines = o.Outlines[:keep:keep]
+		}
+		o.Text = o.Text[:e.Offset]
+		if e.Expanded {
+			expanded[n] = true
+		}
+
+		parent, i, _ := splitPath(e.Path)
+		siblings, _ := childrenAt(doc, parent)
+		*siblings = append((*siblings)[:i+1:i+1], append([]*Outline{n}, (*siblings)[i+1:]...)...)
+		return &Join{Path: copyPath(e.Path)}, nil
+	})
+}
+
+// Join appends the text and children of the next sibling of the outline at
+// Path to it and removes the sibling.
+type Join struct {
+	Path []int
+}
+
+func (e *Join) Apply(doc *OPML) (Edit, error) {
+	return editExpansion(doc, func(expanded map[*Outline]bool) (Edit, error) {
+		o, ok := outlineAt(doc, e.Path)
+		if !ok {
+			return nil, ErrInvalidPath
+		}
+		parent, i, _ := splitPath(e.Path)
+		siblings, _ := childrenAt(doc, parent)
+		if i+1 >= len(*siblings) {
+			return nil, ErrInvalidEdit
+		}
+		next := (*siblings)[i+1]
+
+		template := *next
+		template.Text = ""
+		template.Outlines = nil
+		inverse := &Split{
+			Path:     copyPath(e.Path),
+			Offset:   len(o.Text),
+			Children: len(next.Outlines),
+			Template: &template,
+			Expanded: expanded[next],
+		}
+
+		o.Text += next.Text
+		o.Outlines = append(o.Outlines, next.Outlines...)
+		*siblings = append((*siblings)[:i+1:i+1], (*siblings)[i+2:]...)
+		return inverse, nil
+	})
+}
+
+// applyEdit applies e to the cursor's document. Cursors stay on their
+// outlines; after a Join, cursors on the removed sibling become invalid.
+func (c *Cursor) applyEdit(e Edit) (Edit, error) {
+	inverse, err := e.Apply(c.tree.doc)
+	c.tree.index()
+	return inverse, err
+}
+
+func (c *Cursor) Indent() (Edit, error) {
+	return c.applyEdit(&Indent{Path: c.Path()})
+}
+
+func (c *Cursor) Outdent() (Edit, error) {
+	return c.applyEdit(&Outdent{Path: c.Path()})
+}
+
+func (c *Cursor) MoveUp() (Edit, error) {
+	return c.applyEdit(&MoveUp{Path: c.Path()})
+}
+
+func (c *Cursor) MoveDown() (Edit, error) {
+	return c.applyEdit(&MoveDown{Path: c.Path()})
+}
+
+func (c *Cursor) Split(offset, children int) (Edit, error) {
+	return c.applyEdit(&Split{Path: c.Path(), Offset: offset, Children: children})
+}
+
+func (c *Cursor) Join() (Edit, error) {
+	return c.applyEdit(&Join{Path: c.Path()})
+}
+
+// MoveTo makes the cursor's outline child index of the outline under
+// parent, which must share the cursor's document.
+func (c *Cursor) MoveTo(parent *Cursor, index int) (Edit, error) {
+	if parent.tree.doc != c.tree.doc {
+		return nil, ErrInvalidEdit
+	}
+	path := c.Path()
+	if path == nil {
+		return nil, ErrInvalidPath
+	}
+	// Resolve the parent after detaching, since detaching shifts the
+	// paths of later siblings.
+	target := parent.Path()
+	if target == nil {
+		return nil, ErrInvalidPath
+	}
+	// An outline cannot become its own descendant.
+	if len(target) >= len(path) && equalPath(target[:len(path)], path) {
+		return nil, ErrInvalidEdit
+	}
+	if len(target) >= len(path) && equalPath(target[:len(path)-1], path[:len(path)-1]) && target[len(path)-1] > path[len(path)-1] {
+		target[len(path)-1]--
+	}
+	return c.applyEdit(&Move{Path: path, Parent: target, Index: index})
+}
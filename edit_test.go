@@ -0,0 +1,176 @@
+package opml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func outlineTexts(os []*Outline) []interface{} {
+	var texts []interface{}
+	for _, o := range os {
+		texts = append(texts, o.Text)
+		if len(o.Outlines) > 0 {
+			texts = append(texts, outlineTexts(o.Outlines))
+		}
+	}
+	return texts
+}
+
+func testEdit(t *testing.T, e Edit, want []interface{}, wantState []int) {
+	doc := newPlacesLived()
+	before := outlineTexts(doc.Outlines)
+	beforeState := doc.ExpansionState
+
+	inverse, err := e.Apply(doc)
+	if err != nil {
+		t.Fatalf("Failed to apply %#v: %v", e, err)
+	}
+	if got := outlineTexts(doc.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outline mismatch after %#v\nexpected: %v\ngot: %v\n", e, want, got)
+	}
+	if !reflect.DeepEqual(wantState, doc.ExpansionState) {
+		t.Errorf("ExpansionState mismatch after %#v\nexpected: %v\ngot: %v\n", e, wantState, doc.ExpansionState)
+	}
+
+	if _, err := inverse.Apply(doc); err != nil {
+		t.Fatalf("Failed to apply inverse %#v: %v", inverse, err)
+	}
+	if got := outlineTexts(doc.Outlines); !reflect.DeepEqual(before, got) {
+		t.Errorf("Outline mismatch after inverse %#v\nexpected: %v\ngot: %v\n", inverse, before, got)
+	}
+	if !reflect.DeepEqual(beforeState, doc.ExpansionState) {
+		t.Errorf("ExpansionState mismatch after inverse %#v\nexpected: %v\ngot: %v\n", inverse, beforeState, doc.ExpansionState)
+	}
+}
+
+func TestIndent(t *testing.T) {
+	testEdit(t, &Indent{Path: []int{0, 1}}, []interface{}{
+		"Places I've lived", []interface{}{
+			"Boston", []interface{}{"Cambridge", "West Newton", "Bay Area", []interface{}{"Mountain View", "Los Gatos"}},
+			"New Orleans", []interface{}{"Uptown"},
+		},
+	}, []int{1, 2, 5})
+}
+
+func TestOutdent(t *testing.T) {
+	testEdit(t, &Outdent{Path: []int{0, 0, 0}}, []interface{}{
+		"Places I've lived", []interface{}{
+			"Boston", []interface{}{"West Newton"},
+			"Cambridge",
+			"Bay Area", []interface{}{"Mountain View", "Los Gatos"},
+			"New Orleans", []interface{}{"Uptown"},
+		},
+	}, []int{1, 2, 5})
+}
+
+func TestMoveUpAndDown(t *testing.T) {
+	want := []interface{}{
+		"Places I've lived", []interface{}{
+			"Bay Area", []interface{}{"Mountain View", "Los Gatos"},
+			"Boston", []interface{}{"Cambridge", "West Newton"},
+			"New Orleans", []interface{}{"Uptown"},
+		},
+	}
+	testEdit(t, &MoveUp{Path: []int{0, 1}}, want, []int{1, 2, 5})
+	testEdit(t, &MoveDown{Path: []int{0, 0}}, want, []int{1, 2, 5})
+}
+
+func TestMove(t *testing.T) {
+	testEdit(t, &Move{Path: []int{0, 0}, Parent: []int{0, 0}, Index: 1}, []interface{}{
+		"Places I've lived", []interface{}{
+			"Bay Area", []interface{}{"Mountain View", "Boston", []interface{}{"Cambridge", "West Newton"}, "Los Gatos"},
+			"New Orleans", []interface{}{"Uptown"},
+		},
+	}, []int{1, 2, 4})
+}
+
+func TestSplitAndJoin(t *testing.T) {
+	testEdit(t, &Split{Path: []int{0, 1}, Offset: 3, Children: 1}, []interface{}{
+		"Places I've lived", []interface{}{
+			"Boston", []interface{}{"Cambridge", "West Newton"},
+			"Bay", []interface{}{"Mountain View"},
+			" Area", []interface{}{"Los Gatos"},
+			"New Orleans", []interface{}{"Uptown"},
+		},
+	}, []int{1, 2, 5})
+	testEdit(t, &Join{Path: []int{0, 0}}, []interface{}{
+		"Places I've lived", []interface{}{
+			"BostonBay Area", []interface{}{"Cambridge", "West Newton", "Mountain View", "Los Gatos"},
+			"New Orleans", []interface{}{"Uptown"},
+		},
+	}, []int{1, 2})
+}
+
+func TestJoinRestoresAttributes(t *testing.T) {
+	doc := &OPML{Outlines: []*Outline{
+		{Text: "a"},
+		{Text: "b", Type: "link", URL: parseURL("http://example.com/")},
+	}}
+	want := &OPML{Outlines: []*Outline{
+		{Text: "a"},
+		{Text: "b", Type: "link", URL: parseURL("http://example.com/")},
+	}}
+
+	inverse, err := (&Join{Path: []int{0}}).Apply(doc)
+	if err != nil {
+		t.Fatal("Failed to join:", err)
+	}
+	if _, err := inverse.Apply(doc); err != nil {
+		t.Fatal("Failed to split:", err)
+	}
+	if !reflect.DeepEqual(want, doc) {
+		t.Errorf("OPML mismatch\nexpected: %#v\ngot: %#v\n", want, doc)
+	}
+}
+
+func TestInvalidEdits(t *testing.T) {
+	for _, e := range []Edit{
+		&Indent{Path: []int{0, 0}},
+		&Outdent{Path: []int{0}},
+		&MoveUp{Path: []int{0}},
+		&MoveDown{Path: []int{0, 2}},
+		&Join{Path: []int{0, 2}},
+		&Split{Path: []int{0, 0}, Offset: 100},
+		&Move{Path: []int{0, 0}, Parent: []int{5}},
+		&Indent{Path: []int{0, 9}},
+		&Move{Path: nil},
+	} {
+		doc := newPlacesLived()
+		if _, err := e.Apply(doc); err == nil {
+			t.Errorf("Expected error for %#v", e)
+		}
+		if want := newPlacesLived(); !reflect.DeepEqual(want, doc) {
+			t.Errorf("Document changed by failed edit %#v", e)
+		}
+	}
+}
+
+func TestCursorEditOperations(t *testing.T) {
+	doc := newPlacesLived()
+	root := NewCursor(doc)
+	bayArea := root.At(0, 1)
+	boston := root.At(0, 0)
+
+	if _, err := bayArea.Indent(); err != nil {
+		t.Fatal("Failed to indent:", err)
+	}
+	if want := []int{0, 0, 2}; !reflect.DeepEqual(want, bayArea.Path()) {
+		t.Errorf("Path mismatch\nexpected: %v\ngot: %v\n", want, bayArea.Path())
+	}
+
+	if _, err := boston.MoveTo(root.At(0, 0, 2), 0); err != ErrInvalidEdit {
+		t.Errorf("Error mismatch\nexpected: %v\ngot: %v\n", ErrInvalidEdit, err)
+	}
+
+	// New Orleans moves under Bay Area, whose path shifts once Boston is
+	// detached ahead of it.
+	if _, err := boston.MoveTo(root.At(0, 1), 0); err != nil {
+		t.Fatal("Failed to move:", err)
+	}
+	if want := []int{0, 0, 0}; !reflect.DeepEqual(want, boston.Path()) {
+		t.Errorf("Path mismatch\nexpected: %v\ngot: %v\n", want, boston.Path())
+	}
+	if want := []int{0, 0, 0, 2}; !reflect.DeepEqual(want, bayArea.Path()) {
+		t.Errorf("Path mismatch\nexpected: %v\ngot: %v\n", want, bayArea.Path())
+	}
+}
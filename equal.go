@@ -0,0 +1,145 @@
+package opml
+
+import (
+	"net/url"
+	"time"
+)
+
+type EqualOptions struct {
+	// IgnoreChildOrder compares the children of each outline in any order.
+	IgnoreChildOrder bool
+	// IgnoreDates ignores dateCreated, dateModified and created.
+	IgnoreDates bool
+	// IgnoreWindow ignores the windowTop, windowLeft, windowBottom and
+	// windowRight head elements.
+	IgnoreWindow bool
+	// NilEqualsEmpty treats nil and empty expansionState, categories and
+	// children as equal.
+	NilEqualsEmpty bool
+	// NormalizeURL, when set, compares URLs by the keys it returns.
+	NormalizeURL URLNormalizer
+}
+
+func (opts *EqualOptions) equalURL(a, b *url.URL) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if opts.NormalizeURL != nil {
+		return opts.NormalizeURL(a) == opts.NormalizeURL(b)
+	}
+	return a.String() == b.String()
+}
+
+func (opts *EqualOptions) equalTime(a, b time.Time) bool {
+	return opts.IgnoreDates || a.Equal(b)
+}
+
+// equalNil reports whether a and b are both nil or both not, unless
+// NilEqualsEmpty is set.
+func (opts *EqualOptions) equalNil(aNil, bNil bool) bool {
+	return opts.NilEqualsEmpty || aNil == bNil
+}
+
+func (opts *EqualOptions) equalStrings(a, b []string) bool {
+	if len(a) != len(b) || !opts.equalNil(a == nil, b == nil) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (opts *EqualOptions) equalInts(a, b []int) bool {
+	if len(a) != len(b) || !opts.equalNil(a == nil, b == nil) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (opts *EqualOptions) equalOutlines(a, b []*Outline) bool {
+	if len(a) != len(b) || !opts.equalNil(a == nil, b == nil) {
+		return false
+	}
+	if !opts.IgnoreChildOrder {
+		for i := range a {
+			if !opts.equalOutline(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	used := make([]bool, len(b))
+	for _, o := range a {
+		found := false
+		for j, p := range b {
+			if !used[j] && opts.equalOutline(o, p) {
+				used[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (opts *EqualOptions) equalOutline(a, b *Outline) bool {
+	return a.Text == b.Text &&
+		a.Type == b.Type &&
+		a.IsComment == b.IsComment &&
+		a.IsBreakpoint == b.IsBreakpoint &&
+		opts.equalTime(a.Created, b.Created) &&
+		opts.equalStrings(a.Categories, b.Categories) &&
+		opts.equalURL(a.XMLURL, b.XMLURL) &&
+		a.Description == b.Description &&
+		opts.equalURL(a.HTMLURL, b.HTMLURL) &&
+		a.Language == b.Language &&
+		a.Title == b.Title &&
+		a.Version == b.Version &&
+		opts.equalURL(a.URL, b.URL) &&
+		opts.equalOutlines(a.Outlines, b.Outlines)
+}
+
+// Equal reports whether the outlines and their children are equal. Dates
+// are equal when they are the same instant.
+func (o *Outline) Equal(other *Outline, opts *EqualOptions) bool {
+	if opts == nil {
+		opts = &EqualOptions{}
+	}
+	return opts.equalOutline(o, other)
+}
+
+// Equal reports whether the documents are equal. Dates are equal when
+// they are the same instant.
+func (o *OPML) Equal(other *OPML, opts *EqualOptions) bool {
+	if opts == nil {
+		opts = &EqualOptions{}
+	}
+	window := opts.IgnoreWindow ||
+		o.WindowTop == other.WindowTop &&
+			o.WindowLeft == other.WindowLeft &&
+			o.WindowBottom == other.WindowBottom &&
+			o.WindowRight == other.WindowRight
+	return window &&
+		o.Version == other.Version &&
+		o.Title == other.Title &&
+		opts.equalTime(o.DateCreated, other.DateCreated) &&
+		opts.equalTime(o.DateModified, other.DateModified) &&
+		o.OwnerName == other.OwnerName &&
+		o.OwnerEmail == other.OwnerEmail &&
+		opts.equalURL(o.OwnerID, other.OwnerID) &&
+		opts.equalURL(o.Docs, other.Docs) &&
+		opts.equalInts(o.ExpansionState, other.ExpansionState) &&
+		o.VertScrollState == other.VertScrollState &&
+		opts.equalOutlines(o.Outlines, other.Outlines)
+}
@@ -0,0 +1,82 @@
+package opml
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEqual(t *testing.T) {
+	for _, doc := range []*OPML{specification, subscriptionList, category, placesLived} {
+		if got := doc.Equal(doc.Clone(), nil); !got {
+			t.Errorf("Equal mismatch for %q\nexpected: %v\ngot: %v\n", doc.Title, true, got)
+		}
+	}
+}
+
+func TestEqualOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(doc *OPML)
+		opts   *EqualOptions
+	}{
+		{
+			"child order",
+			func(doc *OPML) {
+				os := doc.Outlines[0].Outlines
+				os[0], os[1] = os[1], os[0]
+			},
+			&EqualOptions{IgnoreChildOrder: true},
+		},
+		{
+			"dates",
+			func(doc *OPML) {
+				doc.DateModified = doc.DateModified.Add(time.Hour)
+				doc.Outlines[0].Created = time.Now()
+			},
+			&EqualOptions{IgnoreDates: true},
+		},
+		{
+			"window",
+			func(doc *OPML) {
+				doc.WindowTop++
+				doc.WindowRight--
+			},
+			&EqualOptions{IgnoreWindow: true},
+		},
+		{
+			"nil and empty",
+			func(doc *OPML) {
+				doc.ExpansionState = nil
+				doc.Outlines[0].Outlines[0].Categories = []string{}
+				doc.Outlines[0].Outlines[0].Outlines = []*Outline{}
+			},
+			&EqualOptions{NilEqualsEmpty: true},
+		},
+		{
+			"URLs",
+			func(doc *OPML) {
+				doc.Outlines[0].Outlines[0].XMLURL = parseURL("https://www.example.com/feed/?utm_source=x")
+			},
+			&EqualOptions{NormalizeURL: NormalizeURL},
+		},
+	}
+	for _, test := range tests {
+		doc := &OPML{
+			ExpansionState: []int{},
+			DateModified:   time.Date(2005, 10, 31, 0, 0, 0, 0, time.UTC),
+			Outlines: []*Outline{{Text: "Folder", Outlines: []*Outline{
+				{Text: "A", XMLURL: parseURL("http://example.com/feed")},
+				{Text: "B"},
+			}}},
+		}
+		changed := doc.Clone()
+		test.change(changed)
+
+		if got := doc.Equal(changed, nil); got {
+			t.Errorf("Equal mismatch for a change in %s\nexpected: %v\ngot: %v\n", test.name, false, got)
+		}
+		if got := doc.Equal(changed, test.opts); !got {
+			t.Errorf("Equal mismatch for a change in %s with %+v\nexpected: %v\ngot: %v\n", test.name, test.opts, true, got)
+		}
+	}
+}
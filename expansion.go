@@ -0,0 +1,43 @@
+package opml
+
+// The expansionState element lists the line numbers of expanded outlines,
+// counting only lines visible at the time each entry is applied. The
+// entries are applied in order, so a number may refer to a line that
+// became visible because of an earlier entry.
+
+func expandedOutlines(outlines []*Outline, state []int) map[*Outline]bool {
+	expanded := map[*Outline]bool{}
+	for _, n := range state {
+		visible := visibleOutlines(outlines, expanded)
+		if n < 1 || n > len(visible) {
+			continue
+		}
+		expanded[visible[n-1]] = true
+	}
+	return expanded
+}
+
+func visibleOutlines(outlines []*Outline, expanded map[*Outline]bool) []*Outline {
+	var visible []*Outline
+	var walk func([]*Outline)
+	walk = func(os []*Outline) {
+		for _, o := range os {
+			visible = append(visible, o)
+			if expanded[o] {
+				walk(o.Outlines)
+			}
+		}
+	}
+	walk(outlines)
+	return visible
+}
+
+func expansionState(outlines []*Outline, expanded map[*Outline]bool) []int {
+	state := []int{}
+	for i, o := range visibleOutlines(outlines, expanded) {
+		if expanded[o] {
+			state = append(state, i+1)
+		}
+	}
+	return state
+}
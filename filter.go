@@ -0,0 +1,135 @@
+package opml
+
+import (
+	"strings"
+	"time"
+)
+
+// IsComment matches outlines commented out with isComment.
+func IsComment(n Node) bool {
+	return n.Outline.IsComment
+}
+
+// InLanguage matches outlines whose language is lang or a variant of it,
+// so "en" matches "en-us".
+func InLanguage(lang string) func(Node) bool {
+	return func(n Node) bool {
+		l := strings.ToLower(n.Outline.Language)
+		lang := strings.ToLower(lang)
+		return l == lang || strings.HasPrefix(l, lang+"-")
+	}
+}
+
+// CreatedBefore matches outlines created before t. Outlines without a
+// creation date do not match.
+func CreatedBefore(t time.Time) func(Node) bool {
+	return func(n Node) bool {
+		return !n.Outline.Created.IsZero() && n.Outline.Created.Before(t)
+	}
+}
+
+type FilterOptions struct {
+	// KeepAncestors keeps the outlines above a match, with only the
+	// matching outlines under them. Otherwise matches take the place of
+	// their dropped ancestors.
+	KeepAncestors bool
+}
+
+type filter struct {
+	pred        func(Node) bool
+	opts        *FilterOptions
+	expanded    map[*Outline]bool
+	newExpanded map[*Outline]bool
+}
+
+// copyExpanded marks the outlines of the copy c that are expanded in o.
+func (f *filter) copyExpanded(o, c *Outline) {
+	if f.expanded[o] {
+		f.newExpanded[c] = true
+	}
+	for i := range o.Outlines {
+		f.copyExpanded(o.Outlines[i], c.Outlines[i])
+	}
+}
+
+func (f *filter) filter(os []*Outline, parent Node) []*Outline {
+	var kept []*Outline
+	for i, o := range os {
+		n := Node{
+			Outline: o,
+			Depth:   len(parent.Path),
+			Path:    append(parent.Path[:len(parent.Path):len(parent.Path)], i),
+		}
+		if parent.Outline != nil {
+			n.Ancestors = append(parent.Ancestors[:len(parent.Ancestors):len(parent.Ancestors)], parent.Outline)
+		}
+
+		if f.pred(n) {
+			c := o.Clone()
+			f.copyExpanded(o, c)
+			kept = append(kept, c)
+			continue
+		}
+
+		children := f.filter(o.Outlines, n)
+		if f.opts.KeepAncestors && len(children) > 0 {
+			folder := *o
+			folder.Outlines = nil
+			c := folder.Clone()
+			c.Outlines = children
+			if f.expanded[o] {
+				f.newExpanded[c] = true
+			}
+			kept = append(kept, c)
+			continue
+		}
+		kept = append(kept, children...)
+	}
+	return kept
+}
+
+// Filter returns a copy of the document with only the outlines pred
+// matches, each with all its children. The head is kept, with
+// expansionState renumbered for the outlines that remain.
+func Filter(doc *OPML, pred func(Node) bool, opts *FilterOptions) *OPML {
+	if opts == nil {
+		opts = &FilterOptions{}
+	}
+	f := &filter{
+		pred:        pred,
+		opts:        opts,
+		expanded:    expandedOutlines(doc.Outlines, doc.ExpansionState),
+		newExpanded: map[*Outline]bool{},
+	}
+
+	head := *doc
+	head.Outlines = nil
+	filtered := head.Clone()
+	filtered.Outlines = f.filter(doc.Outlines, Node{})
+	if doc.ExpansionState != nil {
+		filtered.ExpansionState = expansionState(filtered.Outlines, f.newExpanded)
+	}
+	return filtered
+}
+
+// Prune removes the outlines pred matches, with their children, and
+// returns how many it matched.
+func Prune(doc *OPML, pred func(Node) bool) int {
+	remove := map[*Outline]bool{}
+	doc.Walk(PreOrder, func(n Node) error {
+		if pred(n) {
+			remove[n.Outline] = true
+			return SkipSubtree
+		}
+		return nil
+	})
+	if len(remove) == 0 {
+		return 0
+	}
+
+	editExpansion(doc, func(expanded map[*Outline]bool) (Edit, error) {
+		doc.Outlines = removeOutlines(doc.Outlines, remove)
+		return nil, nil
+	})
+	return len(remove)
+}
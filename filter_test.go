@@ -0,0 +1,91 @@
+package opml
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func textIn(texts ...string) func(Node) bool {
+	return func(n Node) bool {
+		for _, t := range texts {
+			if n.Outline.Text == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		opts      *FilterOptions
+		want      []interface{}
+		wantState []int
+	}{
+		{
+			nil,
+			[]interface{}{"Bay Area", []interface{}{"Mountain View", "Los Gatos"}, "Uptown"},
+			[]int{1},
+		},
+		{
+			&FilterOptions{KeepAncestors: true},
+			[]interface{}{"Places I've lived", []interface{}{"Bay Area", []interface{}{"Mountain View", "Los Gatos"}, "New Orleans", []interface{}{"Uptown"}}},
+			[]int{1, 2},
+		},
+	}
+	for _, test := range tests {
+		doc := newPlacesLived()
+		doc.Title = "Places"
+		filtered := Filter(doc, textIn("Bay Area", "Uptown"), test.opts)
+
+		if got := outlineTexts(filtered.Outlines); !reflect.DeepEqual(test.want, got) {
+			t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", test.want, got)
+		}
+		if !reflect.DeepEqual(test.wantState, filtered.ExpansionState) {
+			t.Errorf("ExpansionState mismatch\nexpected: %v\ngot: %v\n", test.wantState, filtered.ExpansionState)
+		}
+		if filtered.Title != "Places" {
+			t.Errorf("Title mismatch\nexpected: %s\ngot: %s\n", doc.Title, filtered.Title)
+		}
+		if d := Compare(newPlacesLived(), doc, nil); len(d.Outlines) != 0 {
+			t.Errorf("Diff mismatch\nexpected: no outline changes\ngot: %s\n", d)
+		}
+		if filtered.Outlines[0] == doc.Outlines[0] || filtered.Outlines[0] == doc.Outlines[0].Outlines[1] {
+			t.Error("Expected the filtered document not to share outlines")
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	doc := newPlacesLived()
+	if n := Prune(doc, textIn("Boston", "Cambridge", "Uptown")); n != 2 {
+		t.Errorf("Count mismatch\nexpected: %d\ngot: %d\n", 2, n)
+	}
+
+	want := []interface{}{"Places I've lived", []interface{}{"Bay Area", []interface{}{"Mountain View", "Los Gatos"}, "New Orleans"}}
+	if got := outlineTexts(doc.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+	if state := []int{1, 2}; !reflect.DeepEqual(state, doc.ExpansionState) {
+		t.Errorf("ExpansionState mismatch\nexpected: %v\ngot: %v\n", state, doc.ExpansionState)
+	}
+}
+
+func TestFilterPredicates(t *testing.T) {
+	created := time.Date(2005, 10, 31, 0, 0, 0, 0, time.UTC)
+	doc := &OPML{Outlines: []*Outline{
+		{Text: "comment", IsComment: true, Outlines: []*Outline{{Text: "inside"}}},
+		{Text: "english", Language: "en-US"},
+		{Text: "french", Language: "fr", Created: created},
+		{Text: "new", Created: created.AddDate(1, 0, 0)},
+	}}
+
+	Prune(doc, IsComment)
+	Prune(doc, InLanguage("en"))
+	Prune(doc, CreatedBefore(created.AddDate(0, 1, 0)))
+
+	if got, want := outlineTexts(doc.Outlines), []interface{}{"new"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+}
@@ -0,0 +1,200 @@
+package opml
+
+import "strings"
+
+type MultiCategoryMode int
+
+const (
+	// MultiCategoryCopy puts a copy of an outline in the folder of each of
+	// its categories, and merges copies of a feed found in several folders
+	// into one outline with all their categories.
+	MultiCategoryCopy MultiCategoryMode = iota
+	// MultiCategoryFirst puts an outline in the folder of its first
+	// category only, and keeps copies of a feed found in several folders
+	// apart.
+	MultiCategoryFirst
+)
+
+type FolderOptions struct {
+	MultiCategory MultiCategoryMode
+	// KeepEmptyFolders keeps folders left without outlines, and when
+	// flattening, folders that had none.
+	KeepEmptyFolders bool
+	// Tags also turns tags into top-level folders.
+	Tags bool
+}
+
+// isFolder reports whether o groups other outlines: it has children, or
+// neither a type nor URLs.
+func isFolder(o *Outline) bool {
+	return len(o.Outlines) > 0 ||
+		o.Type == "" && o.XMLURL == nil && o.HTMLURL == nil && o.URL == nil
+}
+
+func (opts *FolderOptions) folderCategory(c Category) bool {
+	return c.Domain == "" && len(c.Path) > 0 && (!c.Tag || opts.Tags)
+}
+
+// folderAt returns the folder at the path of texts under the top level,
+// creating missing folders.
+func folderAt(doc *OPML, path []string) *Outline {
+	children := &doc.Outlines
+	var folder *Outline
+	for _, text := range path {
+		folder = nil
+		for _, o := range *children {
+			if o.Text == text && isFolder(o) {
+				folder = o
+				break
+			}
+		}
+		if folder == nil {
+			folder = &Outline{Text: text}
+			*children = append(*children, folder)
+		}
+		children = &folder.Outlines
+	}
+	return folder
+}
+
+// pruneEmptied removes the folders in emptied that are left without
+// children, and then their parents if that leaves them empty in turn.
+func pruneEmptied(os []*Outline, emptied map[*Outline]bool) []*Outline {
+	kept := os[:0]
+	for _, o := range os {
+		o.Outlines = pruneEmptied(o.Outlines, emptied)
+		if len(o.Outlines) == 0 && emptied[o] {
+			continue
+		}
+		kept = append(kept, o)
+	}
+	return kept
+}
+
+// CategoriesToFolders moves outlines with slash-path categories into
+// nested folders named by the path segments, reusing top-level folders with
+// the same text. The categories turned into folders are removed from the
+// outlines.
+func (o *OPML) CategoriesToFolders(opts *FolderOptions) {
+	if opts == nil {
+		opts = &FolderOptions{}
+	}
+
+	editExpansion(o, func(expanded map[*Outline]bool) (Edit, error) {
+		type placement struct {
+			outline *Outline
+			folders []Category
+		}
+		var placements []placement
+		moved := map[*Outline]bool{}
+		hadChildren := map[*Outline]bool{}
+		o.Walk(PreOrder, func(n Node) error {
+			if len(n.Outline.Outlines) > 0 {
+				hadChildren[n.Outline] = true
+			}
+			if isFolder(n.Outline) {
+				return nil
+			}
+			var folders []Category
+			seen := map[string]bool{}
+			for _, c := range n.Outline.ParsedCategories() {
+				if opts.folderCategory(c) && !seen[c.String()] {
+					seen[c.String()] = true
+					folders = append(folders, c)
+					if opts.MultiCategory == MultiCategoryFirst {
+						break
+					}
+				}
+			}
+			if len(folders) > 0 {
+				placements = append(placements, placement{n.Outline, folders})
+				moved[n.Outline] = true
+			}
+			return nil
+		})
+		if len(placements) == 0 {
+			return nil, nil
+		}
+
+		o.Outlines = removeOutlines(o.Outlines, moved)
+		for _, p := range placements {
+			var kept []Category
+			for _, c := range p.outline.ParsedCategories() {
+				converted := false
+				for _, f := range p.folders {
+					converted = converted || c.Equal(f)
+				}
+				if !converted {
+					kept = append(kept, c)
+				}
+			}
+			p.outline.SetCategories(kept...)
+
+			for i, f := range p.folders {
+				outline := p.outline
+				if i > 0 {
+					outline = p.outline.Clone()
+				}
+				folder := folderAt(o, f.Path)
+				folder.Outlines = append(folder.Outlines, outline)
+			}
+		}
+
+		if !opts.KeepEmptyFolders {
+			o.Outlines = pruneEmptied(o.Outlines, hadChildren)
+		}
+		return nil, nil
+	})
+}
+
+// folderFeedID identifies copies of a feed or link in different folders.
+func folderFeedID(o *Outline) string {
+	n := Node{Outline: o}
+	if id := IdentityXMLURL(n); id != "" {
+		return id
+	}
+	return IdentityURL(n)
+}
+
+// FoldersToCategories flattens folders into a list of the outlines in
+// them, adding the path of folder texts, such as "/Tech/Go", to their
+// categories.
+func (o *OPML) FoldersToCategories(opts *FolderOptions) {
+	if opts == nil {
+		opts = &FolderOptions{}
+	}
+
+	editExpansion(o, func(expanded map[*Outline]bool) (Edit, error) {
+		var flat []*Outline
+		byID := map[string]*Outline{}
+		o.Walk(PreOrder, func(n Node) error {
+			outline := n.Outline
+			if isFolder(outline) && (len(outline.Outlines) > 0 || !opts.KeepEmptyFolders) {
+				return nil
+			}
+
+			if len(n.Ancestors) > 0 {
+				texts := make([]string, len(n.Ancestors))
+				for i, a := range n.Ancestors {
+					texts[i] = a.Text
+				}
+				path := "/" + strings.Join(texts, "/")
+				outline.Categories = mergeCategories(outline.Categories, []string{path})
+			}
+
+			id := folderFeedID(outline)
+			if first, ok := byID[id]; ok && opts.MultiCategory == MultiCategoryCopy {
+				first.Categories = mergeCategories(first.Categories, outline.Categories)
+				return nil
+			}
+			if id != "" {
+				byID[id] = outline
+			}
+			flat = append(flat, outline)
+			return nil
+		})
+
+		o.Outlines = flat
+		return nil, nil
+	})
+}
@@ -0,0 +1,121 @@
+package opml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func categorizedDocument() *OPML {
+	return &OPML{Outlines: []*Outline{
+		{Text: "Tech", Outlines: []*Outline{
+			{Text: "Old", Type: "rss", XMLURL: parseURL("http://old.com/rss")},
+		}},
+		{Text: "Go", Type: "rss", XMLURL: parseURL("http://go.dev/rss"), Categories: []string{"/Tech/Go", "/Languages", "golang"}},
+		{Text: "News", Outlines: []*Outline{
+			{Text: "NYT", Type: "rss", XMLURL: parseURL("http://nytimes.com/rss"), Categories: []string{"/News"}},
+		}},
+		{Text: "Plain", Type: "rss", XMLURL: parseURL("http://plain.com/rss")},
+	}}
+}
+
+func TestCategoriesToFolders(t *testing.T) {
+	doc := categorizedDocument()
+	doc.CategoriesToFolders(nil)
+
+	want := []interface{}{
+		"Tech", []interface{}{"Old", "Go", []interface{}{"Go"}},
+		"News", []interface{}{"NYT"},
+		"Plain",
+		"Languages", []interface{}{"Go"},
+	}
+	if got := outlineTexts(doc.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+	goFeed := doc.Outlines[0].Outlines[1].Outlines[0]
+	copied := doc.Outlines[3].Outlines[0]
+	if goFeed == copied {
+		t.Error("Expected a copy in the second folder")
+	}
+	if c := []string{"golang"}; !reflect.DeepEqual(c, goFeed.Categories) || !reflect.DeepEqual(c, copied.Categories) {
+		t.Errorf("Categories mismatch\nexpected: %v\ngot: %v and %v\n", c, goFeed.Categories, copied.Categories)
+	}
+}
+
+func TestCategoriesToFoldersOptions(t *testing.T) {
+	doc := categorizedDocument()
+	doc.Outlines[2].Outlines[0].Categories = []string{"/Daily"}
+	doc.CategoriesToFolders(&FolderOptions{MultiCategory: MultiCategoryFirst, Tags: true})
+
+	want := []interface{}{
+		"Tech", []interface{}{"Old", "Go", []interface{}{"Go"}},
+		"Plain",
+		"Daily", []interface{}{"NYT"},
+	}
+	if got := outlineTexts(doc.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+	if c := []string{"/Languages", "golang"}; !reflect.DeepEqual(c, doc.Outlines[0].Outlines[1].Outlines[0].Categories) {
+		t.Errorf("Categories mismatch\nexpected: %v\ngot: %v\n", c, doc.Outlines[0].Outlines[1].Outlines[0].Categories)
+	}
+
+	doc = categorizedDocument()
+	doc.Outlines[2].Outlines[0].Categories = []string{"/Daily"}
+	doc.CategoriesToFolders(&FolderOptions{MultiCategory: MultiCategoryFirst, KeepEmptyFolders: true})
+	if got := outlineTexts(doc.Outlines); len(got) != 6 || got[2] != "News" {
+		t.Errorf("Outlines mismatch\nexpected: the empty News folder kept\ngot: %v\n", got)
+	}
+}
+
+func TestFoldersToCategories(t *testing.T) {
+	doc := &OPML{
+		ExpansionState: []int{1},
+		Outlines: []*Outline{
+			{Text: "Tech", Outlines: []*Outline{
+				{Text: "Go", Outlines: []*Outline{
+					{Text: "Go Blog", Type: "rss", XMLURL: parseURL("http://go.dev/rss"), Categories: []string{"golang"}},
+				}},
+				{Text: "Empty"},
+			}},
+			{Text: "Favorites", Outlines: []*Outline{
+				{Text: "Go Blog", Type: "rss", XMLURL: parseURL("http://go.dev/rss")},
+			}},
+			{Text: "Plain", Type: "rss", XMLURL: parseURL("http://plain.com/rss")},
+		},
+	}
+	doc.FoldersToCategories(nil)
+
+	want := []interface{}{"Go Blog", "Plain"}
+	if got := outlineTexts(doc.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+	if c := []string{"golang", "/Tech/Go", "/Favorites"}; !reflect.DeepEqual(c, doc.Outlines[0].Categories) {
+		t.Errorf("Categories mismatch\nexpected: %v\ngot: %v\n", c, doc.Outlines[0].Categories)
+	}
+	if doc.Outlines[1].Categories != nil {
+		t.Errorf("Categories mismatch\nexpected: %v\ngot: %v\n", nil, doc.Outlines[1].Categories)
+	}
+	if len(doc.ExpansionState) != 0 {
+		t.Errorf("ExpansionState mismatch\nexpected: %v\ngot: %v\n", []int{}, doc.ExpansionState)
+	}
+}
+
+func TestFoldersToCategoriesOptions(t *testing.T) {
+	doc := &OPML{Outlines: []*Outline{
+		{Text: "Tech", Outlines: []*Outline{
+			{Text: "Go Blog", XMLURL: parseURL("http://go.dev/rss")},
+			{Text: "Empty"},
+		}},
+		{Text: "Favorites", Outlines: []*Outline{
+			{Text: "Go Blog", XMLURL: parseURL("http://go.dev/rss")},
+		}},
+	}}
+	doc.FoldersToCategories(&FolderOptions{MultiCategory: MultiCategoryFirst, KeepEmptyFolders: true})
+
+	want := []interface{}{"Go Blog", "Empty", "Go Blog"}
+	if got := outlineTexts(doc.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+	if c := []string{"/Tech"}; !reflect.DeepEqual(c, doc.Outlines[1].Categories) {
+		t.Errorf("Categories mismatch\nexpected: %v\ngot: %v\n", c, doc.Outlines[1].Categories)
+	}
+}
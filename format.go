@@ -0,0 +1,191 @@
+package opml
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+	"sync"
+
+	"golang.org/x/net/html/charset"
+)
+
+// A Format converts between *OPML and another representation of an
+// outline. Formats that can only be written leave Sniff and Decode nil,
+// and formats that can only be read leave Encode nil.
+type Format struct {
+	Name string
+	// Sniff reports whether the first bytes of an input are in this format.
+	Sniff  func(head []byte) bool
+	Decode func(r io.Reader) (*OPML, error)
+	Encode func(w io.Writer, opml *OPML) error
+}
+
+// sniffLen is the number of bytes passed to Format.Sniff.
+const sniffLen = 1024
+
+var (
+	ErrUnknownFormat = errors.New("opml: unknown format")
+	ErrNotDecodable  = errors.New("opml: format cannot be decoded")
+	ErrNotEncodable  = errors.New("opml: format cannot be encoded")
+)
+
+var (
+	formatsMu sync.RWMutex
+	formats   []*Format
+)
+
+// RegisterFormat adds a format to the registry. Formats are sniffed in
+// registration order, and registering a name again replaces the earlier
+// format in place.
+func RegisterFormat(f *Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	for i, existing := range formats {
+		if existing.Name == f.Name {
+			formats[i] = f
+			return
+		}
+	}
+	formats = append(formats, f)
+}
+
+func LookupFormat(name string) *Format {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	for _, f := range formats {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func Formats() []*Format {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	return append([]*Format(nil), formats...)
+}
+
+// DetectFormat sniffs the format of r. The returned reader yields the
+// whole input, including the bytes consumed while sniffing.
+func DetectFormat(r io.Reader) (*Format, io.Reader, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, br, err
+	}
+
+	for _, f := range Formats() {
+		if f.Sniff != nil && f.Decode != nil && f.Sniff(head) {
+			return f, br, nil
+		}
+	}
+	return nil, br, ErrUnknownFormat
+}
+
+func Import(r io.Reader) (*OPML, error) {
+	f, r, err := DetectFormat(r)
+	if err != nil {
+		return nil, err
+	}
+	return f.Decode(r)
+}
+
+func Export(w io.Writer, format string, opml *OPML) error {
+	f := LookupFormat(format)
+	if f == nil {
+		return ErrUnknownFormat
+	}
+	if f.Encode == nil {
+		return ErrNotEncodable
+	}
+	return f.Encode(w, opml)
+}
+
+// ImportFormat decodes r as the named format without sniffing.
+func ImportFormat(r io.Reader, format string) (*OPML, error) {
+	f := LookupFormat(format)
+	if f == nil {
+		return nil, ErrUnknownFormat
+	}
+	if f.Decode == nil {
+		return nil, ErrNotDecodable
+	}
+	return f.Decode(r)
+}
+
+func sniffPattern(pattern string) func([]byte) bool {
+	re := regexp.MustCompile(pattern)
+	return func(head []byte) bool {
+		return re.Match(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")))
+	}
+}
+
+func decodeOPML(r io.Reader) (*OPML, error) {
+	p := NewParser(r)
+	p.XMLDecoder.CharsetReader = charset.NewReaderLabel
+	return p.Parse()
+}
+
+func decodeMindMap(r io.Reader) (*OPML, error) {
+	p := NewMindMapParser(r)
+	p.XMLDecoder.CharsetReader = charset.NewReaderLabel
+	return p.Parse()
+}
+
+func encodeDOT(w io.Writer, opml *OPML) error {
+	return RenderDOT(w, opml, nil)
+}
+
+func encodeMermaid(w io.Writer, opml *OPML) error {
+	return RenderMermaid(w, opml, nil)
+}
+
+func encodeSVG(w io.Writer, opml *OPML) error {
+	return RenderSVG(w, opml, nil)
+}
+
+func init() {
+	RegisterFormat(&Format{
+		Name:   "opml",
+		Sniff:  sniffPattern(`^\s*(<\?xml[^>]*>\s*)?(<!--[\s\S]*?-->\s*)*<opml[\s>]`),
+		Decode: decodeOPML,
+		Encode: Render,
+	})
+	RegisterFormat(&Format{
+		Name:   "freemind",
+		Sniff:  sniffPattern(`^\s*(<\?xml[^>]*>\s*)?(<!--[\s\S]*?-->\s*)*<map[\s>]`),
+		Decode: decodeMindMap,
+		Encode: RenderMindMap,
+	})
+	RegisterFormat(&Format{
+		Name:   "bookmarks",
+		Sniff:  sniffPattern(`(?i)^\s*<!DOCTYPE\s+NETSCAPE-Bookmark-file-1`),
+		Decode: ParseBookmarks,
+		Encode: RenderBookmarks,
+	})
+	RegisterFormat(&Format{
+		Name:   "mastodon-csv",
+		Sniff:  sniffPattern(`^"?Account address"?,`),
+		Decode: ParseMastodonFollowing,
+	})
+	RegisterFormat(&Format{
+		Name:   "youtube-csv",
+		Sniff:  sniffPattern(`^"?Channel Id"?,`),
+		Decode: ParseYouTubeSubscriptions,
+	})
+	RegisterFormat(&Format{
+		Name:   "newsboat",
+		Sniff:  sniffPattern(`^(\s*(#[^\n]*)?\n)*\s*"?(https?://|query:|exec:|filter:)`),
+		Decode: ParseNewsboat,
+		Encode: RenderNewsboat,
+	})
+	RegisterFormat(&Format{Name: "dot", Encode: encodeDOT})
+	RegisterFormat(&Format{Name: "mermaid", Encode: encodeMermaid})
+	RegisterFormat(&Format{Name: "svg", Encode: encodeSVG})
+}
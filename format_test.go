@@ -0,0 +1,127 @@
+package opml
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"specification.opml", "opml"},
+		{"placesLived.mm", "freemind"},
+		{"bookmarks.html", "bookmarks"},
+		{"following_accounts.csv", "mastodon-csv"},
+		{"subscriptions.csv", "youtube-csv"},
+		{"urls", "newsboat"},
+	}
+
+	for _, test := range tests {
+		f, _, err := DetectFormat(openTestData(test.filename))
+		if err != nil {
+			t.Errorf("Failed to detect format of %s: %v", test.filename, err)
+			continue
+		}
+		if f.Name != test.want {
+			t.Errorf("Format mismatch for %s\nexpected: %s\ngot: %s\n", test.filename, test.want, f.Name)
+		}
+	}
+}
+
+func TestDetectFormatUnknown(t *testing.T) {
+	_, _, err := DetectFormat(strings.NewReader("plain text"))
+	if err != ErrUnknownFormat {
+		t.Errorf("Error mismatch\nexpected: %v\ngot: %v\n", ErrUnknownFormat, err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     *OPML
+	}{
+		{"specification.opml", specification},
+		{"placesLived.mm", placesLivedMindMap},
+		{"bookmarks.html", bookmarks},
+	}
+
+	for _, test := range tests {
+		got, err := Import(openTestData(test.filename))
+		if err != nil {
+			t.Errorf("Failed to import %s: %v", test.filename, err)
+		}
+
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("OPML mismatch\nexpected: %#v\ngot: %#v\n", test.want, got)
+		}
+	}
+}
+
+func TestImportKeepsSniffedBytes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, states); err != nil {
+		t.Error("Failed to render OPML:", err)
+	}
+
+	got, err := Import(io.MultiReader(&buf))
+	if err != nil {
+		t.Error("Failed to import:", err)
+	}
+	if len(got.Outlines) != 1 || len(got.Outlines[0].Outlines) != 8 {
+		t.Errorf("OPML mismatch\nexpected: %#v\ngot: %#v\n", states, got)
+	}
+}
+
+func TestExport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Export(&buf, "newsboat", newsboatCategories); err != nil {
+		t.Error("Failed to export:", err)
+	}
+	if !strings.HasPrefix(buf.String(), "http://www.scripting.com/rss.xml") {
+		t.Errorf("Unexpected export\ngot: %s\n", buf.String())
+	}
+
+	if err := Export(&buf, "nonexistent", newsboatCategories); err != ErrUnknownFormat {
+		t.Errorf("Error mismatch\nexpected: %v\ngot: %v\n", ErrUnknownFormat, err)
+	}
+	if err := Export(&buf, "youtube-csv", newsboatCategories); err != ErrNotEncodable {
+		t.Errorf("Error mismatch\nexpected: %v\ngot: %v\n", ErrNotEncodable, err)
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	f := &Format{
+		Name:  "test-format",
+		Sniff: func(head []byte) bool { return bytes.HasPrefix(head, []byte("TEST")) },
+		Decode: func(r io.Reader) (*OPML, error) {
+			return &OPML{Title: "test"}, nil
+		},
+	}
+	RegisterFormat(f)
+	defer func() {
+		formatsMu.Lock()
+		defer formatsMu.Unlock()
+		for i, existing := range formats {
+			if existing == f {
+				formats = append(formats[:i:i], formats[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	got, err := Import(strings.NewReader("TEST"))
+	if err != nil {
+		t.Error("Failed to import:", err)
+	}
+	if got.Title != "test" {
+		t.Errorf("Title mismatch\nexpected: %s\ngot: %s\n", "test", got.Title)
+	}
+	if LookupFormat("test-format") != f {
+		t.Error("Registered format not found")
+	}
+}
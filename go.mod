@@ -2,4 +2,7 @@ module github.com/kaorimatz/go-opml
 
 go 1.15
 
-require golang.org/x/net v0.0.0-20210119194325-5f4716e94777
+require (
+	golang.org/x/net v0.0.0-20210119194325-5f4716e94777
+	golang.org/x/text v0.3.3
+)
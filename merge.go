@@ -0,0 +1,258 @@
+package opml
+
+import "fmt"
+
+type MergeConflictKind string
+
+const (
+	// ConflictField is a field both sides changed to different values.
+	ConflictField MergeConflictKind = "field"
+	// ConflictHead is a head element both sides changed to different
+	// values.
+	ConflictHead MergeConflictKind = "head"
+	// ConflictAdd is an outline both sides added with different fields.
+	ConflictAdd MergeConflictKind = "add"
+	// ConflictMove is an outline both sides moved to different parents.
+	ConflictMove MergeConflictKind = "move"
+	// ConflictDelete is an outline one side removed while the other
+	// changed it or something inside it.
+	ConflictDelete MergeConflictKind = "delete"
+	// ConflictParent is an outline theirs added or moved under a parent
+	// ours removed.
+	ConflictParent MergeConflictKind = "parent"
+	// ConflictApply is a change from theirs that could not be applied to
+	// the merged document for the Reason given.
+	ConflictApply MergeConflictKind = "apply"
+)
+
+// A MergeConflict is a change from theirs that could not be combined with
+// ours. The merged document keeps ours in every conflict.
+type MergeConflict struct {
+	Kind   MergeConflictKind `json:"kind"`
+	ID     string            `json:"id"`
+	Field  string            `json:"field,omitempty"`
+	Base   string            `json:"base,omitempty"`
+	Ours   string            `json:"ours,omitempty"`
+	Theirs string            `json:"theirs,omitempty"`
+	Reason string            `json:"reason,omitempty"`
+}
+
+func (c MergeConflict) String() string {
+	if c.Field != "" {
+		return fmt.Sprintf("%s conflict on %s %s: base %q, ours %q, theirs %q", c.Kind, c.ID, c.Field, c.Base, c.Ours, c.Theirs)
+	}
+	if c.Reason != "" {
+		return fmt.Sprintf("%s conflict on %s: %s", c.Kind, c.ID, c.Reason)
+	}
+	return fmt.Sprintf("%s conflict on %s", c.Kind, c.ID)
+}
+
+type MergeOptions struct {
+	// Identity matches outlines between the documents. Defaults to
+	// DefaultIdentity.
+	Identity IdentityFunc
+	// MarkConflicts adds a comment outline describing each conflict after
+	// the outline it concerns.
+	MarkConflicts bool
+}
+
+// Merge3 combines the changes ours and theirs made to base. The result
+// starts as a copy of ours, and the changes theirs made are applied to it
+// where they do not conflict with ours.
+func Merge3(base, ours, theirs *OPML, opts *MergeOptions) (*OPML, []MergeConflict) {
+	identity := DefaultIdentity
+	if opts != nil && opts.Identity != nil {
+		identity = opts.Identity
+	}
+
+	result := ours.Clone()
+	_, baseByID := identify(base, identity)
+	_, oursByID := identify(ours, identity)
+	theirsDiff := Compare(base, theirs, &DiffOptions{Identity: identity})
+	oursMoved := map[string]bool{}
+	for _, c := range Compare(base, ours, &DiffOptions{Identity: identity}).Outlines {
+		if c.Kind == Moved {
+			oursMoved[c.ID] = true
+		}
+	}
+
+	var conflicts []MergeConflict
+	var ops []PatchOp
+
+	for _, f := range theirsDiff.Head {
+		o := headField(ours, f.Field)
+		switch {
+		case o == f.Old:
+			ops = append(ops, PatchOp{Op: PatchHead, Field: f.Field, Old: o, New: f.New})
+		case o != f.New:
+			conflicts = append(conflicts, MergeConflict{Kind: ConflictHead, Field: f.Field, Base: f.Old, Ours: o, Theirs: f.New})
+		}
+	}
+
+	removed := map[string]bool{}
+	added := map[string]bool{}
+	for _, c := range theirsDiff.Outlines {
+		switch c.Kind {
+		case Removed:
+			removed[c.ID] = true
+		case Added:
+			added[c.ID] = true
+		}
+	}
+	// hasParent reports whether the parent theirs placed an outline under
+	// is still in the merged document.
+	hasParent := func(id string) bool {
+		_, ok := oursByID[id]
+		return id == "" || ok || added[id]
+	}
+
+	for _, c := range theirsDiff.Outlines {
+		on, inOurs := oursByID[c.ID]
+
+		switch c.Kind {
+		case Added:
+			if inOurs {
+				conflicts = append(conflicts, compareAdded(c.ID, on.Outline, c.New)...)
+				continue
+			}
+			if !hasParent(c.NewParent) {
+				conflicts = append(conflicts, MergeConflict{Kind: ConflictParent, ID: c.ID, Theirs: c.NewParent})
+				continue
+			}
+			ops = append(ops, PatchOp{Op: PatchAdd, ID: c.ID, Parent: c.NewParent, After: c.After, Fields: outlineFields(c.New)})
+
+		case Moved:
+			if !inOurs {
+				conflicts = append(conflicts, MergeConflict{Kind: ConflictDelete, ID: c.ID})
+				continue
+			}
+			switch {
+			case oursMoved[c.ID] && on.parentID == c.NewParent:
+			case oursMoved[c.ID]:
+				conflicts = append(conflicts, MergeConflict{Kind: ConflictMove, ID: c.ID, Base: c.OldParent, Ours: on.parentID, Theirs: c.NewParent})
+			case !hasParent(c.NewParent):
+				conflicts = append(conflicts, MergeConflict{Kind: ConflictParent, ID: c.ID, Theirs: c.NewParent})
+			default:
+				ops = append(ops, PatchOp{Op: PatchMove, ID: c.ID, Parent: c.NewParent, After: c.After})
+			}
+
+		case Renamed, Modified:
+			if !inOurs {
+				conflicts = append(conflicts, MergeConflict{Kind: ConflictDelete, ID: c.ID})
+				continue
+			}
+			for _, f := range c.Fields {
+				o := outlineAttrString(on.Outline, f.Field)
+				switch {
+				case o == f.Old:
+					ops = append(ops, PatchOp{Op: PatchSet, ID: c.ID, Field: f.Field, Old: o, New: f.New})
+				case o != f.New:
+					conflicts = append(conflicts, MergeConflict{Kind: ConflictField, ID: c.ID, Field: f.Field, Base: f.Old, Ours: o, Theirs: f.New})
+				}
+			}
+		}
+	}
+
+	// The patcher resolves IDs against result as it is now, a copy of ours,
+	// so that changes do not renumber outlines sharing a key.
+	p := newPatcher(result, identity)
+	for _, op := range ops {
+		if reason := p.apply(op); reason != "" {
+			conflicts = append(conflicts, MergeConflict{Kind: ConflictApply, ID: op.ID, Field: op.Field, Reason: reason})
+		}
+	}
+
+	// Removals go last, once outlines theirs moved out of removed folders
+	// are gone from them.
+	for _, c := range theirsDiff.Outlines {
+		if c.Kind != Removed || removed[c.OldParent] {
+			continue
+		}
+		o, ok := p.byID[c.ID]
+		if !ok {
+			continue
+		}
+		if _, ok := p.locate(o); !ok {
+			continue
+		}
+		if p.changedInside(o, baseByID, removed) {
+			conflicts = append(conflicts, MergeConflict{Kind: ConflictDelete, ID: c.ID})
+			continue
+		}
+		if reason := p.apply(PatchOp{Op: PatchRemove, ID: c.ID}); reason != "" {
+			conflicts = append(conflicts, MergeConflict{Kind: ConflictApply, ID: c.ID, Reason: reason})
+		}
+	}
+
+	if opts != nil && opts.MarkConflicts {
+		p.markConflicts(conflicts)
+	}
+	return result, conflicts
+}
+
+func compareAdded(id string, ours, theirs *Outline) []MergeConflict {
+	var conflicts []MergeConflict
+	for _, name := range outlineAttrNames {
+		o, t := outlineAttrString(ours, name), outlineAttrString(theirs, name)
+		if o != t {
+			conflicts = append(conflicts, MergeConflict{Kind: ConflictAdd, ID: id, Field: name, Ours: o, Theirs: t})
+		}
+	}
+	return conflicts
+}
+
+// changedInside reports whether ours changed the outline o, or anything
+// under it, since base. Removing such an outline would lose ours' changes.
+func (p *patcher) changedInside(o *Outline, baseByID map[string]*diffNode, removed map[string]bool) bool {
+	ids := map[*Outline]string{}
+	for id, o := range p.byID {
+		ids[o] = id
+	}
+	n, _ := p.locate(o)
+	parentID := ids[n.Parent()]
+
+	changed := false
+	o.Walk(PreOrder, func(m Node) error {
+		id, mParentID := ids[m.Outline], parentID
+		if m.Outline != o {
+			mParentID = ids[m.Parent()]
+		}
+		b, ok := baseByID[id]
+		if !ok || !removed[id] || b.parentID != mParentID {
+			changed = true
+			return StopWalk
+		}
+		for _, name := range outlineAttrNames {
+			if outlineAttrString(b.Outline, name) != outlineAttrString(m.Outline, name) {
+				changed = true
+				return StopWalk
+			}
+		}
+		return nil
+	})
+	return changed
+}
+
+// markConflicts adds a comment after the outline each conflict concerns,
+// or at the end of the top level if it is not in the document.
+func (p *patcher) markConflicts(conflicts []MergeConflict) {
+	for _, c := range conflicts {
+		comment := &Outline{Text: c.String(), IsComment: true}
+
+		o, ok := p.byID[c.ID]
+		var n Node
+		if ok {
+			n, ok = p.locate(o)
+		}
+		if !ok {
+			p.doc.Outlines = append(p.doc.Outlines, comment)
+			continue
+		}
+		siblings := &p.doc.Outlines
+		if parent := n.Parent(); parent != nil {
+			siblings = &parent.Outlines
+		}
+		i := n.Path[len(n.Path)-1] + 1
+		*siblings = append((*siblings)[:i:i], append([]*Outline{comment}, (*siblings)[i:]...)...)
+	}
+}
@@ -0,0 +1,175 @@
+package opml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	base, theirs := diffDocuments()
+	ours, _ := diffDocuments()
+	ours.Outlines[1].Outlines[0].Description = "Times"
+	ours.Outlines[1].Outlines = append(ours.Outlines[1].Outlines, &Outline{Text: "Ars", Type: "rss", XMLURL: parseURL("http://arstechnica.com/rss.xml")})
+
+	merged, conflicts := Merge3(base, ours, theirs, nil)
+	if len(conflicts) != 0 {
+		t.Errorf("Conflicts mismatch\nexpected: %v\ngot: %v\n", nil, conflicts)
+	}
+
+	if merged.Title != "My subscriptions" {
+		t.Errorf("Title mismatch\nexpected: %s\ngot: %s\n", "My subscriptions", merged.Title)
+	}
+	want := []interface{}{"Tech", []interface{}{"Scripting News!", "NYT"}, "News", []interface{}{"CNET", "Ars"}}
+	if got := outlineTexts(merged.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+	nyt := merged.Outlines[0].Outlines[1]
+	if nyt.Language != "en-us" || nyt.Description != "Times" {
+		t.Errorf("NYT mismatch\nexpected: %q, %q\ngot: %q, %q\n", "en-us", "Times", nyt.Language, nyt.Description)
+	}
+	if len(ours.Outlines[1].Outlines) != 2 {
+		t.Error("Expected ours to be unchanged")
+	}
+}
+
+func TestMerge3Conflicts(t *testing.T) {
+	base, theirs := diffDocuments()
+	ours, _ := diffDocuments()
+	ours.Outlines[0].Outlines[1].Text = "Wired Magazine"
+	ours.Outlines[1].Outlines[0].Language = "fr"
+
+	merged, conflicts := Merge3(base, ours, theirs, &MergeOptions{MarkConflicts: true})
+
+	want := []MergeConflict{
+		{Kind: ConflictField, ID: "xmlUrl:http://www.nytimes.com/rss.xml", Field: "language", Base: "en", Ours: "fr", Theirs: "en-us"},
+		{Kind: ConflictDelete, ID: "xmlUrl:http://www.wired.com/rss.xml"},
+	}
+	if !reflect.DeepEqual(want, conflicts) {
+		t.Errorf("Conflicts mismatch\nexpected: %v\ngot: %v\n", want, conflicts)
+	}
+
+	tech := merged.Outlines[0].Outlines
+	wantTexts := []interface{}{"Scripting News!", "NYT", want[0].String(), "Wired Magazine", want[1].String()}
+	if got := outlineTexts(tech); !reflect.DeepEqual(wantTexts, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", wantTexts, got)
+	}
+	if !tech[2].IsComment || !tech[4].IsComment {
+		t.Error("Expected conflict markers to be comments")
+	}
+	if tech[1].Language != "fr" {
+		t.Errorf("Language mismatch\nexpected: %s\ngot: %s\n", "fr", tech[1].Language)
+	}
+}
+
+func TestMerge3Moves(t *testing.T) {
+	feed := func() *Outline { return &Outline{Text: "Feed", XMLURL: parseURL("http://example.com/feed")} }
+	base := &OPML{Outlines: []*Outline{{Text: "A", Outlines: []*Outline{feed()}}, {Text: "B"}}}
+	ours := &OPML{Outlines: []*Outline{{Text: "A"}, {Text: "B", Outlines: []*Outline{feed()}}}}
+	theirs := &OPML{Outlines: []*Outline{{Text: "A"}, {Text: "B"}, {Text: "C", Outlines: []*Outline{feed()}}}}
+
+	merged, conflicts := Merge3(base, ours, theirs, nil)
+	want := []MergeConflict{{Kind: ConflictMove, ID: "xmlUrl:http://example.com/feed", Base: `path:"A"`, Ours: `path:"B"`, Theirs: `path:"C"`}}
+	if !reflect.DeepEqual(want, conflicts) {
+		t.Errorf("Conflicts mismatch\nexpected: %v\ngot: %v\n", want, conflicts)
+	}
+	wantTexts := []interface{}{"A", "B", []interface{}{"Feed"}, "C"}
+	if got := outlineTexts(merged.Outlines); !reflect.DeepEqual(wantTexts, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", wantTexts, got)
+	}
+
+	// Without a conflicting move, the feed follows theirs into the new
+	// folder.
+	merged, conflicts = Merge3(base, base, theirs, nil)
+	if len(conflicts) != 0 {
+		t.Errorf("Conflicts mismatch\nexpected: %v\ngot: %v\n", nil, conflicts)
+	}
+	wantTexts = []interface{}{"A", "B", "C", []interface{}{"Feed"}}
+	if got := outlineTexts(merged.Outlines); !reflect.DeepEqual(wantTexts, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", wantTexts, got)
+	}
+}
+
+func TestMerge3Reorder(t *testing.T) {
+	a := &OPML{Outlines: []*Outline{{Text: "A"}, {Text: "B"}}}
+	b := &OPML{Outlines: []*Outline{{Text: "B"}, {Text: "A"}}}
+
+	merged, conflicts := Merge3(a, a, b, nil)
+	if len(conflicts) != 0 {
+		t.Errorf("Conflicts mismatch\nexpected: %v\ngot: %v\n", nil, conflicts)
+	}
+	want := []interface{}{"B", "A"}
+	if got := outlineTexts(merged.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+
+	// Ours keeps its own reorder.
+	c := &OPML{Outlines: []*Outline{{Text: "B"}, {Text: "A"}, {Text: "C"}}}
+	merged, conflicts = Merge3(a, b, c, nil)
+	if len(conflicts) != 0 {
+		t.Errorf("Conflicts mismatch\nexpected: %v\ngot: %v\n", nil, conflicts)
+	}
+	want = []interface{}{"B", "A", "C"}
+	if got := outlineTexts(merged.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+}
+
+func TestMerge3Duplicates(t *testing.T) {
+	feed := func(rawurl string) *Outline { return &Outline{Text: "Feed", XMLURL: parseURL(rawurl)} }
+	base := &OPML{Outlines: []*Outline{
+		{Text: "Tech", Outlines: []*Outline{feed("http://e.com/rss")}},
+		{Text: "News", Outlines: []*Outline{feed("http://e.com/rss")}},
+	}}
+	theirs := &OPML{Outlines: []*Outline{
+		{Text: "Tech", Outlines: []*Outline{feed("http://e.com/x")}},
+		{Text: "News", Outlines: []*Outline{feed("http://e.com/y")}},
+	}}
+
+	merged, conflicts := Merge3(base, base, theirs, nil)
+	if len(conflicts) != 0 {
+		t.Errorf("Conflicts mismatch\nexpected: %v\ngot: %v\n", nil, conflicts)
+	}
+	if d := Compare(theirs, merged, nil); !d.Empty() {
+		t.Errorf("Merged document differs from theirs:\n%s", d)
+	}
+}
+
+func TestMerge3Permutations(t *testing.T) {
+	docs := []*OPML{
+		{Outlines: []*Outline{{Text: "t3", Outlines: []*Outline{{Text: "t1"}}}, {Text: "t1", Outlines: []*Outline{{Text: "t0"}}}, {Text: "t0"}}},
+		{Outlines: []*Outline{{Text: "t0"}, {Text: "t1", Outlines: []*Outline{{Text: "t0"}}}, {Text: "t3", Outlines: []*Outline{{Text: "t1"}}}}},
+		{Outlines: []*Outline{{Text: "t0"}, {Text: "t0"}, {Text: "t1"}, {Text: "t0"}}},
+		{Outlines: []*Outline{{Text: "t1"}, {Text: "t0", XMLURL: parseURL("http://e.com/rss")}, {Text: "t0", XMLURL: parseURL("http://e.com/rss")}}},
+		{},
+	}
+	opts := &EqualOptions{NilEqualsEmpty: true}
+	for _, a := range docs {
+		for _, b := range docs {
+			// A change on one side only comes through as it is.
+			if merged, conflicts := Merge3(a, a, b, nil); len(conflicts) != 0 || !merged.Equal(b, opts) {
+				t.Errorf("Merge mismatch for theirs\nexpected: %v\ngot: %v %v\n", outlineTexts(b.Outlines), outlineTexts(merged.Outlines), conflicts)
+			}
+			if merged, conflicts := Merge3(a, b, a, nil); len(conflicts) != 0 || !merged.Equal(b, opts) {
+				t.Errorf("Merge mismatch for ours\nexpected: %v\ngot: %v %v\n", outlineTexts(b.Outlines), outlineTexts(merged.Outlines), conflicts)
+			}
+		}
+	}
+}
+
+func TestMerge3ApplyConflict(t *testing.T) {
+	folder := func(text string, children ...*Outline) *Outline {
+		return &Outline{Text: text, URL: parseURL("http://e.com/" + text), Outlines: children}
+	}
+	base := &OPML{Outlines: []*Outline{folder("P"), folder("Q")}}
+	ours := &OPML{Outlines: []*Outline{folder("Q", folder("P"))}}
+	theirs := &OPML{Outlines: []*Outline{folder("P", folder("Q"))}}
+
+	merged, conflicts := Merge3(base, ours, theirs, nil)
+	want := []MergeConflict{{Kind: ConflictApply, ID: "url:http://e.com/Q", Reason: "parent is inside the outline"}}
+	if !reflect.DeepEqual(want, conflicts) {
+		t.Errorf("Conflicts mismatch\nexpected: %v\ngot: %v\n", want, conflicts)
+	}
+	if d := Compare(ours, merged, nil); !d.Empty() {
+		t.Errorf("Merged document differs from ours:\n%s", d)
+	}
+}
@@ -0,0 +1,157 @@
+package opml
+
+import (
+	"encoding/xml"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type xmlMindMap struct {
+	XMLName xml.Name        `xml:"map"`
+	Version string          `xml:"version,attr,omitempty"`
+	Node    *xmlMindMapNode `xml:"node"`
+}
+
+func (xm *xmlMindMap) ToOPML() *OPML {
+	o := &OPML{}
+	if xm.Node == nil {
+		return o
+	}
+
+	o.Title = xm.Node.Text
+	o.DateCreated = time.Time(xm.Node.Created)
+	o.DateModified = time.Time(xm.Node.Modified)
+
+	expanded := map[*Outline]bool{}
+	o.Outlines = xm.Node.Nodes.ToOutlines(expanded)
+	o.ExpansionState = expansionState(o.Outlines, expanded)
+	return o
+}
+
+func (xm *xmlMindMap) FromOPML(o *OPML) {
+	xm.Version = "1.0.1"
+	xm.Node = &xmlMindMapNode{
+		Text:     o.Title,
+		Created:  xmlMindMapTime(o.DateCreated),
+		Modified: xmlMindMapTime(o.DateModified),
+	}
+
+	var expanded map[*Outline]bool
+	if o.ExpansionState != nil {
+		expanded = expandedOutlines(o.Outlines, o.ExpansionState)
+	}
+	xm.Node.Nodes.FromOutlines(o.Outlines, expanded)
+}
+
+type xmlMindMapNode struct {
+	Text     string          `xml:"TEXT,attr"`
+	Link     *xmlURL         `xml:"LINK,attr,omitempty"`
+	Folded   bool            `xml:"FOLDED,attr,omitempty"`
+	Created  xmlMindMapTime  `xml:"CREATED,attr,omitempty"`
+	Modified xmlMindMapTime  `xml:"MODIFIED,attr,omitempty"`
+	Nodes    xmlMindMapNodes `xml:"node"`
+}
+
+func (xn *xmlMindMapNode) ToOutline(expanded map[*Outline]bool) *Outline {
+	o := &Outline{
+		Text:     xn.Text,
+		Created:  time.Time(xn.Created),
+		URL:      (*url.URL)(xn.Link),
+		Outlines: xn.Nodes.ToOutlines(expanded),
+	}
+	if len(xn.Nodes) > 0 && !xn.Folded {
+		expanded[o] = true
+	}
+	return o
+}
+
+func (xn *xmlMindMapNode) FromOutline(o *Outline, expanded map[*Outline]bool) {
+	xn.Text = o.Text
+	xn.Created = xmlMindMapTime(o.Created)
+	xn.Link = (*xmlURL)(o.URL)
+	xn.Folded = expanded != nil && len(o.Outlines) > 0 && !expanded[o]
+	xn.Nodes.FromOutlines(o.Outlines, expanded)
+}
+
+type xmlMindMapNodes []*xmlMindMapNode
+
+func (xns xmlMindMapNodes) ToOutlines(expanded map[*Outline]bool) []*Outline {
+	if xns == nil {
+		return nil
+	}
+
+	outlines := make([]*Outline, len(xns))
+	for i, n := range xns {
+		outlines[i] = n.ToOutline(expanded)
+	}
+	return outlines
+}
+
+func (xns *xmlMindMapNodes) FromOutlines(os []*Outline, expanded map[*Outline]bool) {
+	for _, o := range os {
+		var xn xmlMindMapNode
+		xn.FromOutline(o, expanded)
+		*xns = append(*xns, &xn)
+	}
+}
+
+// FreeMind stores timestamps as milliseconds since the Unix epoch.
+type xmlMindMapTime time.Time
+
+func (t *xmlMindMapTime) UnmarshalXMLAttr(attr xml.Attr) error {
+	ms, err := strconv.ParseInt(attr.Value, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	*t = xmlMindMapTime(time.Unix(0, ms*int64(time.Millisecond)).UTC())
+	return nil
+}
+
+func (t xmlMindMapTime) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if time.Time(t).IsZero() {
+		return xml.Attr{}, nil
+	}
+	ms := time.Time(t).UnixNano() / int64(time.Millisecond)
+	return xml.Attr{Name: name, Value: strconv.FormatInt(ms, 10)}, nil
+}
+
+type MindMapParser struct {
+	XMLDecoder *xml.Decoder
+}
+
+func NewMindMapParser(r io.Reader) *MindMapParser {
+	return &MindMapParser{XMLDecoder: xml.NewDecoder(r)}
+}
+
+func (p *MindMapParser) Parse() (*OPML, error) {
+	var xmlMindMap xmlMindMap
+	if err := p.XMLDecoder.Decode(&xmlMindMap); err != nil {
+		return nil, err
+	}
+	return xmlMindMap.ToOPML(), nil
+}
+
+func ParseMindMap(r io.Reader) (*OPML, error) {
+	return NewMindMapParser(r).Parse()
+}
+
+type MindMapRenderer struct {
+	XMLEncoder *xml.Encoder
+}
+
+func NewMindMapRenderer(w io.Writer) *MindMapRenderer {
+	return &MindMapRenderer{XMLEncoder: xml.NewEncoder(w)}
+}
+
+func (r *MindMapRenderer) Render(opml *OPML) error {
+	var xmlMindMap xmlMindMap
+	xmlMindMap.FromOPML(opml)
+	return r.XMLEncoder.Encode(xmlMindMap)
+}
+
+func RenderMindMap(w io.Writer, opml *OPML) error {
+	return NewMindMapRenderer(w).Render(opml)
+}
@@ -0,0 +1,83 @@
+package opml
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var placesLivedMindMap = &OPML{
+	Title:          "placesLived.opml",
+	DateCreated:    time.Date(2006, 2, 27, 12, 9, 48, 0, time.UTC),
+	DateModified:   time.Date(2006, 2, 27, 12, 11, 44, 0, time.UTC),
+	ExpansionState: []int{1, 2},
+	Outlines: []*Outline{
+		{
+			Text:    "Places I've lived",
+			Created: time.Date(2006, 2, 27, 12, 9, 48, 0, time.UTC),
+			Outlines: []*Outline{
+				{
+					Text: "Boston",
+					Outlines: []*Outline{
+						{Text: "Cambridge"},
+						{Text: "West Newton"},
+					},
+				},
+				{
+					Text: "Bay Area",
+					Outlines: []*Outline{
+						{Text: "Mountain View"},
+						{Text: "Los Gatos"},
+					},
+				},
+				{Text: "Florida", URL: parseURL("http://hosting.opml.org/dave/florida.opml")},
+			},
+		},
+	},
+}
+
+func TestParseMindMap(t *testing.T) {
+	got, err := ParseMindMap(openTestData("placesLived.mm"))
+	if err != nil {
+		t.Error("Failed to parse mind map:", err)
+	}
+
+	if !reflect.DeepEqual(placesLivedMindMap, got) {
+		t.Errorf("OPML mismatch\nexpected: %#v\ngot: %#v\n", placesLivedMindMap, got)
+	}
+}
+
+func TestRenderMindMap(t *testing.T) {
+	var buf bytes.Buffer
+	err := RenderMindMap(&buf, placesLivedMindMap)
+	if err != nil {
+		t.Error("Failed to render mind map:", err)
+	}
+
+	if !strings.Contains(buf.String(), `<node TEXT="Bay Area" FOLDED="true">`) {
+		t.Errorf("Folded node not rendered\ngot: %s\n", buf.String())
+	}
+
+	got, err := ParseMindMap(&buf)
+	if err != nil {
+		t.Error("Failed to parse mind map:", err)
+	}
+
+	if !reflect.DeepEqual(placesLivedMindMap, got) {
+		t.Errorf("OPML mismatch\nexpected: %#v\ngot: %#v\n", placesLivedMindMap, got)
+	}
+}
+
+func TestRenderMindMapWithoutExpansionState(t *testing.T) {
+	var buf bytes.Buffer
+	err := RenderMindMap(&buf, &OPML{Outlines: placesLivedMindMap.Outlines})
+	if err != nil {
+		t.Error("Failed to render mind map:", err)
+	}
+
+	if strings.Contains(buf.String(), "FOLDED") {
+		t.Errorf("Unexpected folded node\ngot: %s\n", buf.String())
+	}
+}
@@ -0,0 +1,211 @@
+package opml
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+type NewsboatTagMode int
+
+const (
+	// NewsboatTagsAsCategories stores tags in Outline.Categories.
+	NewsboatTagsAsCategories NewsboatTagMode = iota
+	// NewsboatTagsAsFolders files each feed under a folder named after its
+	// first tag.
+	NewsboatTagsAsFolders
+)
+
+type NewsboatOptions struct {
+	Tags NewsboatTagMode
+}
+
+var errNewsboatQuote = errors.New("unterminated quote")
+
+// splitNewsboatLine splits a urls file line into fields the way newsboat
+// does: fields are separated by whitespace, double quotes group a field and
+// a backslash escapes the next character inside quotes. Text after an
+// unquoted # is a comment.
+func splitNewsboatLine(line string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	inField, quoted := false, false
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quoted && c == '\\' && i+1 < len(line):
+			i++
+			field.WriteByte(line[i])
+		case c == '"':
+			quoted = !quoted
+			inField = true
+		case quoted:
+			field.WriteByte(c)
+		case c == '#' && !inField:
+			i = len(line)
+		case c == ' ' || c == '\t':
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteByte(c)
+			inField = true
+		}
+	}
+	if quoted {
+		return nil, errNewsboatQuote
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
+
+type NewsboatParser struct {
+	Reader  io.Reader
+	Options NewsboatOptions
+}
+
+func NewNewsboatParser(r io.Reader) *NewsboatParser {
+	return &NewsboatParser{Reader: r}
+}
+
+func (p *NewsboatParser) Parse() (*OPML, error) {
+	o := &OPML{Version: "2.0"}
+	folders := map[string]*Outline{}
+
+	scanner := bufio.NewScanner(p.Reader)
+	for n := 1; scanner.Scan(); n++ {
+		fields, err := splitNewsboatLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		outline, tags, err := newsboatOutline(fields)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+
+		if p.Options.Tags == NewsboatTagsAsFolders && len(tags) > 0 {
+			folder, ok := folders[tags[0]]
+			if !ok {
+				folder = &Outline{Text: tags[0]}
+				folders[tags[0]] = folder
+				o.Outlines = append(o.Outlines, folder)
+			}
+			folder.Outlines = append(folder.Outlines, outline)
+			tags = tags[1:]
+		} else {
+			o.Outlines = append(o.Outlines, outline)
+		}
+		if len(tags) > 0 {
+			outline.Categories = tags
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func newsboatOutline(fields []string) (*Outline, []string, error) {
+	o := &Outline{}
+
+	var tags []string
+	for _, f := range fields[1:] {
+		if strings.HasPrefix(f, "~") {
+			o.Title = f[1:]
+		} else {
+			tags = append(tags, f)
+		}
+	}
+
+	// Query feeds have no URL to subscribe to, so they are kept as
+	// comments holding the original line.
+	if strings.HasPrefix(fields[0], "query:") {
+		o.Text = fields[0]
+		o.IsComment = true
+		return o, tags, nil
+	}
+
+	u, err := url.Parse(fields[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	o.Type = "rss"
+	o.XMLURL = u
+	o.Text = o.Title
+	if o.Text == "" {
+		o.Text = fields[0]
+	}
+	return o, tags, nil
+}
+
+func ParseNewsboat(r io.Reader) (*OPML, error) {
+	return NewNewsboatParser(r).Parse()
+}
+
+type NewsboatRenderer struct {
+	Writer io.Writer
+}
+
+func NewNewsboatRenderer(w io.Writer) *NewsboatRenderer {
+	return &NewsboatRenderer{Writer: w}
+}
+
+// Render writes one line per feed or query outline. Folders become tags of
+// the feeds they contain.
+func (r *NewsboatRenderer) Render(opml *OPML) error {
+	w := bufio.NewWriter(r.Writer)
+	var render func([]*Outline, []string)
+	render = func(os []*Outline, folders []string) {
+		for _, o := range os {
+			switch {
+			case o.IsComment && strings.HasPrefix(o.Text, "query:"):
+				writeNewsboatLine(w, o.Text, o, folders)
+			case o.XMLURL != nil:
+				writeNewsboatLine(w, o.XMLURL.String(), o, folders)
+			}
+			if len(o.Outlines) > 0 {
+				render(o.Outlines, append(folders[:len(folders):len(folders)], o.Text))
+			}
+		}
+	}
+	render(opml.Outlines, nil)
+	return w.Flush()
+}
+
+func writeNewsboatLine(w *bufio.Writer, first string, o *Outline, folders []string) {
+	w.WriteString(quoteNewsboatField(first))
+	for _, tag := range append(folders[:len(folders):len(folders)], o.Categories...) {
+		w.WriteString(" ")
+		w.WriteString(quoteNewsboatField(tag))
+	}
+	if o.Title != "" {
+		w.WriteString(" ")
+		w.WriteString(quoteNewsboatField("~" + o.Title))
+	}
+	w.WriteString("\n")
+}
+
+var newsboatReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func quoteNewsboatField(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"\\#") {
+		return s
+	}
+	return `"` + newsboatReplacer.Replace(s) + `"`
+}
+
+func RenderNewsboat(w io.Writer, opml *OPML) error {
+	return NewNewsboatRenderer(w).Render(opml)
+}
@@ -0,0 +1,124 @@
+package opml
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+var newsboatCategories = &OPML{
+	Version: "2.0",
+	Outlines: []*Outline{
+		{
+			Text:       "Scripting News",
+			Type:       "rss",
+			Title:      "Scripting News",
+			XMLURL:     parseURL("http://www.scripting.com/rss.xml"),
+			Categories: []string{"news"},
+		},
+		{
+			Text:       "http://www.wired.com/news_drop/netcenter/netcenter.rdf",
+			Type:       "rss",
+			XMLURL:     parseURL("http://www.wired.com/news_drop/netcenter/netcenter.rdf"),
+			Categories: []string{"news", "tech"},
+		},
+		{
+			Text:       "https://example.com/feed.xml",
+			Type:       "rss",
+			XMLURL:     parseURL("https://example.com/feed.xml"),
+			Categories: []string{"Read Later"},
+		},
+		{
+			Text:      `query:Unread Articles:unread = "yes"`,
+			IsComment: true,
+			Title:     "All unread",
+		},
+	},
+}
+
+var newsboatFolders = &OPML{
+	Version: "2.0",
+	Outlines: []*Outline{
+		{
+			Text: "news",
+			Outlines: []*Outline{
+				{
+					Text:   "Scripting News",
+					Type:   "rss",
+					Title:  "Scripting News",
+					XMLURL: parseURL("http://www.scripting.com/rss.xml"),
+				},
+				{
+					Text:       "http://www.wired.com/news_drop/netcenter/netcenter.rdf",
+					Type:       "rss",
+					XMLURL:     parseURL("http://www.wired.com/news_drop/netcenter/netcenter.rdf"),
+					Categories: []string{"tech"},
+				},
+			},
+		},
+		{
+			Text: "Read Later",
+			Outlines: []*Outline{
+				{
+					Text:   "https://example.com/feed.xml",
+					Type:   "rss",
+					XMLURL: parseURL("https://example.com/feed.xml"),
+				},
+			},
+		},
+		{
+			Text:      `query:Unread Articles:unread = "yes"`,
+			IsComment: true,
+			Title:     "All unread",
+		},
+	},
+}
+
+func TestParseNewsboat(t *testing.T) {
+	got, err := ParseNewsboat(openTestData("urls"))
+	if err != nil {
+		t.Error("Failed to parse newsboat urls:", err)
+	}
+
+	if !reflect.DeepEqual(newsboatCategories, got) {
+		t.Errorf("OPML mismatch\nexpected: %#v\ngot: %#v\n", newsboatCategories, got)
+	}
+}
+
+func TestParseNewsboatAsFolders(t *testing.T) {
+	parser := NewNewsboatParser(openTestData("urls"))
+	parser.Options.Tags = NewsboatTagsAsFolders
+	got, err := parser.Parse()
+	if err != nil {
+		t.Error("Failed to parse newsboat urls:", err)
+	}
+
+	if !reflect.DeepEqual(newsboatFolders, got) {
+		t.Errorf("OPML mismatch\nexpected: %#v\ngot: %#v\n", newsboatFolders, got)
+	}
+}
+
+func TestRenderNewsboat(t *testing.T) {
+	want := `http://www.scripting.com/rss.xml news "~Scripting News"
+http://www.wired.com/news_drop/netcenter/netcenter.rdf news tech
+https://example.com/feed.xml "Read Later"
+"query:Unread Articles:unread = \"yes\"" "~All unread"
+`
+	for _, o := range []*OPML{newsboatCategories, newsboatFolders} {
+		var buf bytes.Buffer
+		if err := RenderNewsboat(&buf, o); err != nil {
+			t.Error("Failed to render newsboat urls:", err)
+		}
+
+		if got := buf.String(); got != want {
+			t.Errorf("Newsboat urls mismatch\nexpected: %s\ngot: %s\n", want, got)
+		}
+	}
+}
+
+func TestParseNewsboatUnterminatedQuote(t *testing.T) {
+	_, err := ParseNewsboat(bytes.NewBufferString("http://example.com/feed \"tag\n"))
+	if err == nil {
+		t.Error("Expected error for unterminated quote")
+	}
+}
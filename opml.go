@@ -408,9 +408,11 @@ func NewRenderer(w io.Writer) *Renderer {
 	return &Renderer{XMLEncoder: xml.NewEncoder(w)}
 }
 
+// Render encodes the document, applying the defaults of registered type
+// handlers to the outlines of their types without modifying opml.
 func (r *Renderer) Render(opml *OPML) error {
 	var xmlOPML xmlOPML
-	xmlOPML.FromOPML(opml)
+	xmlOPML.FromOPML(withTypeDefaults(opml))
 	return r.XMLEncoder.Encode(xmlOPML)
 }
 
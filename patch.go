@@ -0,0 +1,438 @@
+package opml
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	identitiesMu sync.RWMutex
+	identities   = map[string]IdentityFunc{
+		"":         DefaultIdentity,
+		"default":  DefaultIdentity,
+		"xmlUrl":   IdentityXMLURL,
+		"url":      IdentityURL,
+		"textPath": IdentityTextPath,
+	}
+)
+
+// RegisterIdentity makes fn available to patches under name.
+func RegisterIdentity(name string, fn IdentityFunc) {
+	identitiesMu.Lock()
+	defer identitiesMu.Unlock()
+
+	identities[name] = fn
+}
+
+func lookupIdentity(name string) (IdentityFunc, bool) {
+	identitiesMu.RLock()
+	defer identitiesMu.RUnlock()
+
+	fn, ok := identities[name]
+	return fn, ok
+}
+
+type PatchOpKind string
+
+const (
+	PatchAdd    PatchOpKind = "add"
+	PatchRemove PatchOpKind = "remove"
+	PatchMove   PatchOpKind = "move"
+	PatchSet    PatchOpKind = "set"
+	PatchHead   PatchOpKind = "head"
+)
+
+// A PatchOp is one change in a Patch. Outlines are addressed by ID, their
+// identity in the document before the patch or given by the add op that
+// created them, or by Path when ID is empty. Placement is by identity
+// only: Parent and After place added and moved outlines, Parent being the
+// identity of the new parent, empty for the top level, and After the
+// identity of the previous sibling, empty for the first position. Set and head ops change Field from Old to New, using the
+// attribute and head element names of the OPML format.
+type PatchOp struct {
+	Op     PatchOpKind       `json:"op"`
+	ID     string            `json:"id,omitempty"`
+	Path   []int             `json:"path,omitempty"`
+	Parent string            `json:"parent,omitempty"`
+	After  string            `json:"after,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+	Field  string            `json:"field,omitempty"`
+	Old    string            `json:"old,omitempty"`
+	New    string            `json:"new,omitempty"`
+}
+
+// A Patch is a serializable list of changes to a document. Identity names
+// the IdentityFunc used for outline IDs: "default", "xmlUrl", "url",
+// "textPath" or one added with RegisterIdentity.
+type Patch struct {
+	Identity string    `json:"identity,omitempty"`
+	Ops      []PatchOp `json:"ops"`
+}
+
+type Conflict struct {
+	Op     PatchOp `json:"op"`
+	Reason string  `json:"reason"`
+}
+
+// A ConflictError lists the ops of a patch that could not be applied.
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	reasons := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		reasons[i] = fmt.Sprintf("%s %s: %s", c.Op.Op, c.Op.ID, c.Reason)
+	}
+	return "opml: patch conflicts: " + strings.Join(reasons, "; ")
+}
+
+func outlineFields(o *Outline) map[string]string {
+	fields := map[string]string{}
+	for _, name := range outlineAttrNames {
+		if values, ok := outlineAttr(o, strings.ToLower(name)); ok {
+			fields[name] = strings.Join(values, ",")
+		}
+	}
+	return fields
+}
+
+// Patch converts the diff to a patch. identity must name the IdentityFunc
+// the diff was computed with.
+func (d *Diff) Patch(identity string) *Patch {
+	p := &Patch{Identity: identity, Ops: []PatchOp{}}
+	for _, f := range d.Head {
+		p.Ops = append(p.Ops, PatchOp{Op: PatchHead, Field: f.Field, Old: f.Old, New: f.New})
+	}
+
+	// Additions and moves go first so that outlines moved out of removed
+	// folders are not removed with them.
+	removed := map[string]bool{}
+	for _, c := range d.Outlines {
+		switch c.Kind {
+		case Added:
+			p.Ops = append(p.Ops, PatchOp{Op: PatchAdd, ID: c.ID, Parent: c.NewParent, After: c.After, Fields: outlineFields(c.New)})
+		case Moved:
+			p.Ops = append(p.Ops, PatchOp{Op: PatchMove, ID: c.ID, Parent: c.NewParent, After: c.After})
+		case Renamed, Modified:
+			for _, f := range c.Fields {
+				p.Ops = append(p.Ops, PatchOp{Op: PatchSet, ID: c.ID, Field: f.Field, Old: f.Old, New: f.New})
+			}
+		case Removed:
+			removed[c.ID] = true
+		}
+	}
+	for _, c := range d.Outlines {
+		if c.Kind == Removed && !removed[c.OldParent] {
+			p.Ops = append(p.Ops, PatchOp{Op: PatchRemove, ID: c.ID})
+		}
+	}
+	return p
+}
+
+// MakePatch returns the patch that turns old into new.
+func MakePatch(old, new *OPML, identity string) (*Patch, error) {
+	fn, ok := lookupIdentity(identity)
+	if !ok {
+		return nil, fmt.Errorf("opml: unknown identity %q", identity)
+	}
+	return Compare(old, new, &DiffOptions{Identity: fn}).Patch(identity), nil
+}
+
+// A patcher applies ops to doc. It resolves IDs against the identities
+// the outlines had before the first op, plus those of added outlines, so
+// that changes do not renumber outlines sharing a key.
+type patcher struct {
+	doc  *OPML
+	byID map[string]*Outline
+}
+
+func newPatcher(doc *OPML, identity IdentityFunc) *patcher {
+	p := &patcher{doc: doc, byID: map[string]*Outline{}}
+	nodes, _ := identify(doc, identity)
+	for _, n := range nodes {
+		p.byID[n.id] = n.Outline
+	}
+	return p
+}
+
+// locate finds o in the document, returning false if it is no longer
+// there.
+func (p *patcher) locate(o *Outline) (Node, bool) {
+	return p.doc.Find(func(n Node) bool { return n.Outline == o })
+}
+
+func (p *patcher) lookup(id string, path []int) (*Outline, *[]*Outline, int, bool) {
+	if id == "" {
+		parent, i, ok := splitPath(path)
+		if !ok {
+			return nil, nil, 0, false
+		}
+		siblings, ok := childrenAt(p.doc, parent)
+		if !ok || i < 0 || i >= len(*siblings) {
+			return nil, nil, 0, false
+		}
+		return (*siblings)[i], siblings, i, true
+	}
+
+	o, ok := p.byID[id]
+	if !ok {
+		return nil, nil, 0, false
+	}
+	n, ok := p.locate(o)
+	if !ok {
+		return nil, nil, 0, false
+	}
+	siblings := &p.doc.Outlines
+	if parent := n.Parent(); parent != nil {
+		siblings = &parent.Outlines
+	}
+	return o, siblings, n.Path[len(n.Path)-1], true
+}
+
+// insert places o under the outline identified by parent, after the
+// sibling identified by after. An unknown after appends o.
+func (p *patcher) insert(o *Outline, parent, after string) string {
+	children := &p.doc.Outlines
+	if parent != "" {
+		po, ok := p.byID[parent]
+		if !ok {
+			return "parent not found"
+		}
+		if _, ok := p.locate(po); !ok {
+			return "parent not found"
+		}
+		children = &po.Outlines
+	}
+
+	i := len(*children)
+	if after == "" {
+		i = 0
+	} else if a, ok := p.byID[after]; ok {
+		for j, c := range *children {
+			if c == a {
+				i = j + 1
+			}
+		}
+	}
+	*children = append((*children)[:i:i], append([]*Outline{o}, (*children)[i:]...)...)
+	return ""
+}
+
+// inside reports whether the outline identified by id is o or one of its
+// descendants.
+func (p *patcher) inside(id string, o *Outline) bool {
+	po, ok := p.byID[id]
+	if !ok {
+		return false
+	}
+	n, ok := p.locate(po)
+	if !ok {
+		return false
+	}
+	for _, a := range append(n.Ancestors, po) {
+		if a == o {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *patcher) apply(op PatchOp) string {
+	switch op.Op {
+	case PatchHead:
+		if current := headField(p.doc, op.Field); current != op.Old {
+			return fmt.Sprintf("%s is %q, expected %q", op.Field, current, op.Old)
+		}
+		if err := setHeadField(p.doc, op.Field, op.New); err != nil {
+			return err.Error()
+		}
+		return ""
+
+	case PatchAdd:
+		if o, ok := p.byID[op.ID]; ok && op.ID != "" {
+			if _, ok := p.locate(o); ok {
+				return "outline already exists"
+			}
+		}
+		o := &Outline{}
+		for name, value := range op.Fields {
+			if err := setOutlineAttr(o, name, value); err != nil {
+				return err.Error()
+			}
+		}
+		if reason := p.insert(o, op.Parent, op.After); reason != "" {
+			return reason
+		}
+		if op.ID != "" {
+			p.byID[op.ID] = o
+		}
+		return ""
+	}
+
+	o, siblings, i, ok := p.lookup(op.ID, op.Path)
+	if !ok {
+		return "outline not found"
+	}
+
+	switch op.Op {
+	case PatchRemove:
+		*siblings = append((*siblings)[:i:i], (*siblings)[i+1:]...)
+	case PatchMove:
+		if p.inside(op.Parent, o) {
+			return "parent is inside the outline"
+		}
+		detached := append((*siblings)[:i:i], (*siblings)[i+1:]...)
+		*siblings = detached
+		if reason := p.insert(o, op.Parent, op.After); reason != "" {
+			*siblings = append(detached[:i:i], append([]*Outline{o}, detached[i:]...)...)
+			return reason
+		}
+	case PatchSet:
+		if current := outlineAttrString(o, op.Field); current != op.Old {
+			return fmt.Sprintf("%s is %q, expected %q", op.Field, current, op.Old)
+		}
+		if err := setOutlineAttr(o, op.Field, op.New); err != nil {
+			return err.Error()
+		}
+	default:
+		return fmt.Sprintf("unknown op %q", op.Op)
+	}
+	return ""
+}
+
+// Apply applies the ops of patch to doc in order. Ops that conflict with
+// the document, such as a set whose old value does not match or a remove
+// of a missing outline, are skipped and reported in a *ConflictError; the
+// other ops are still applied.
+func Apply(doc *OPML, patch *Patch) error {
+	identity, ok := lookupIdentity(patch.Identity)
+	if !ok {
+		return fmt.Errorf("opml: unknown identity %q", patch.Identity)
+	}
+
+	p := newPatcher(doc, identity)
+	var conflicts []Conflict
+	for _, op := range patch.Ops {
+		if reason := p.apply(op); reason != "" {
+			conflicts = append(conflicts, Conflict{Op: op, Reason: reason})
+		}
+	}
+	if len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+func parseOptionalURL(v string) (*url.URL, error) {
+	if v == "" {
+		return nil, nil
+	}
+	return url.Parse(v)
+}
+
+func parseOptionalTime(layout, v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(layout, v)
+}
+
+// setOutlineAttr sets the attribute name of o from its string form as
+// produced by outlineAttr.
+func setOutlineAttr(o *Outline, name, value string) error {
+	var err error
+	switch name {
+	case "text":
+		o.Text = value
+	case "type":
+		o.Type = value
+	case "isComment":
+		o.IsComment = value == "true"
+	case "isBreakpoint":
+		o.IsBreakpoint = value == "true"
+	case "created":
+		o.Created, err = parseOptionalTime(time.RFC3339, value)
+	case "category":
+		o.Categories = nil
+		if value != "" {
+			o.Categories = strings.Split(value, ",")
+		}
+	case "xmlUrl":
+		o.XMLURL, err = parseOptionalURL(value)
+	case "description":
+		o.Description = value
+	case "htmlUrl":
+		o.HTMLURL, err = parseOptionalURL(value)
+	case "language":
+		o.Language = value
+	case "title":
+		o.Title = value
+	case "version":
+		o.Version = value
+	case "url":
+		o.URL, err = parseOptionalURL(value)
+	default:
+		return fmt.Errorf("unknown attribute %q", name)
+	}
+	return err
+}
+
+func setHeadField(o *OPML, name, value string) error {
+	atoi := func(v string) (int, error) {
+		if v == "" {
+			return 0, nil
+		}
+		return strconv.Atoi(v)
+	}
+
+	var err error
+	switch name {
+	case "version":
+		o.Version = value
+	case "title":
+		o.Title = value
+	case "dateCreated":
+		o.DateCreated, err = parseOptionalTime(time.RFC1123, value)
+	case "dateModified":
+		o.DateModified, err = parseOptionalTime(time.RFC1123, value)
+	case "ownerName":
+		o.OwnerName = value
+	case "ownerEmail":
+		o.OwnerEmail = value
+	case "ownerId":
+		o.OwnerID, err = parseOptionalURL(value)
+	case "docs":
+		o.Docs, err = parseOptionalURL(value)
+	case "expansionState":
+		state := []int{}
+		for _, s := range strings.Split(value, ",") {
+			if s == "" {
+				continue
+			}
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return err
+			}
+			state = append(state, n)
+		}
+		o.ExpansionState = state
+	case "vertScrollState":
+		o.VertScrollState, err = atoi(value)
+	case "windowTop":
+		o.WindowTop, err = atoi(value)
+	case "windowLeft":
+		o.WindowLeft, err = atoi(value)
+	case "windowBottom":
+		o.WindowBottom, err = atoi(value)
+	case "windowRight":
+		o.WindowRight, err = atoi(value)
+	default:
+		return fmt.Errorf("unknown head element %q", name)
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+package opml
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	for _, identity := range []string{"default", "textPath"} {
+		old, new := diffDocuments()
+		patch, err := MakePatch(old, new, identity)
+		if err != nil {
+			t.Fatal("Failed to make patch:", err)
+		}
+
+		b, err := json.Marshal(patch)
+		if err != nil {
+			t.Fatal("Failed to marshal patch:", err)
+		}
+		var decoded Patch
+		if err := json.Unmarshal(b, &decoded); err != nil {
+			t.Fatal("Failed to unmarshal patch:", err)
+		}
+
+		if err := Apply(old, &decoded); err != nil {
+			t.Errorf("Failed to apply %s patch: %v", identity, err)
+		}
+		if d := Compare(old, new, nil); !d.Empty() {
+			t.Errorf("Documents differ after %s patch %s:\n%s", identity, b, d)
+		}
+	}
+}
+
+func TestApplyPatchMovedOutOfRemovedFolder(t *testing.T) {
+	old := &OPML{Outlines: []*Outline{
+		{Text: "Old", Outlines: []*Outline{
+			{Text: "Feed", XMLURL: parseURL("http://example.com/feed")},
+			{Text: "Gone", XMLURL: parseURL("http://example.com/gone")},
+		}},
+	}}
+	new := &OPML{Outlines: []*Outline{
+		{Text: "New", Outlines: []*Outline{
+			{Text: "Feed", XMLURL: parseURL("http://example.com/feed")},
+		}},
+	}}
+
+	patch, err := MakePatch(old, new, "")
+	if err != nil {
+		t.Fatal("Failed to make patch:", err)
+	}
+	if err := Apply(old, patch); err != nil {
+		t.Error("Failed to apply patch:", err)
+	}
+	if d := Compare(old, new, nil); !d.Empty() {
+		t.Errorf("Documents differ after patch:\n%s", d)
+	}
+}
+
+func TestApplyPatchReorder(t *testing.T) {
+	old := &OPML{Outlines: []*Outline{
+		{Text: "Tech", Outlines: []*Outline{{Text: "Go"}, {Text: "Rust"}, {Text: "Zig"}}},
+		{Text: "News"},
+	}}
+	new := &OPML{Outlines: []*Outline{
+		{Text: "News"},
+		{Text: "Tech", Outlines: []*Outline{{Text: "Zig"}, {Text: "Go"}, {Text: "Rust"}}},
+	}}
+
+	patch, err := MakePatch(old, new, "")
+	if err != nil {
+		t.Fatal("Failed to make patch:", err)
+	}
+	if len(patch.Ops) != 2 {
+		t.Errorf("Op count mismatch\nexpected: %d\ngot: %d (%v)\n", 2, len(patch.Ops), patch.Ops)
+	}
+	if err := Apply(old, patch); err != nil {
+		t.Error("Failed to apply patch:", err)
+	}
+	want := []interface{}{"News", "Tech", []interface{}{"Zig", "Go", "Rust"}}
+	if got := outlineTexts(old.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+}
+
+func TestApplyPatchDuplicates(t *testing.T) {
+	feed := func() *Outline { return &Outline{Text: "Feed", XMLURL: parseURL("http://e.com/rss")} }
+	old := &OPML{Outlines: []*Outline{
+		{Text: "Tech", Outlines: []*Outline{feed(), {Text: "Go", XMLURL: parseURL("http://go.dev/rss")}}},
+		{Text: "News", Outlines: []*Outline{feed()}},
+		{Text: "Misc", Outlines: []*Outline{feed()}},
+	}}
+	new := &OPML{Outlines: []*Outline{
+		{Text: "Tech", Outlines: []*Outline{{Text: "Go", XMLURL: parseURL("http://go.dev/rss")}}},
+		{Text: "News"},
+		{Text: "Misc", Outlines: []*Outline{feed(), {Text: "Added", XMLURL: parseURL("http://e.com/rss")}}},
+	}}
+
+	patch, err := MakePatch(old, new, "")
+	if err != nil {
+		t.Fatal("Failed to make patch:", err)
+	}
+	if err := Apply(old, patch); err != nil {
+		t.Error("Failed to apply patch:", err)
+	}
+	if d := Compare(old, new, nil); !d.Empty() {
+		t.Errorf("Documents differ after patch:\n%s", d)
+	}
+}
+
+func TestApplyPatchConflicts(t *testing.T) {
+	doc, _ := diffDocuments()
+	patch := &Patch{Ops: []PatchOp{
+		{Op: PatchSet, ID: "xmlUrl:http://www.wired.com/rss.xml", Field: "text", Old: "Wired News", New: "Wired!"},
+		{Op: PatchRemove, ID: "xmlUrl:http://example.com/missing"},
+		{Op: PatchAdd, ID: "xmlUrl:http://www.wired.com/rss.xml", Fields: map[string]string{"text": "Wired"}},
+		{Op: PatchMove, ID: `path:"Tech"`, Parent: "xmlUrl:http://www.wired.com/rss.xml"},
+		{Op: PatchHead, Field: "title", Old: "Subscriptions", New: "Feeds"},
+		{Op: PatchSet, Path: []int{1, 0}, Field: "language", Old: "en", New: "fr"},
+	}}
+
+	err := Apply(doc, patch)
+	cerr, ok := err.(*ConflictError)
+	if !ok {
+		t.Fatalf("Expected *ConflictError, got %v", err)
+	}
+	if len(cerr.Conflicts) != 4 {
+		t.Errorf("Conflict count mismatch\nexpected: %d\ngot: %d (%v)\n", 4, len(cerr.Conflicts), cerr)
+	}
+	if want := "parent is inside the outline"; len(cerr.Conflicts) == 4 && cerr.Conflicts[3].Reason != want {
+		t.Errorf("Reason mismatch\nexpected: %s\ngot: %s\n", want, cerr.Conflicts[3].Reason)
+	}
+	if doc.Title != "Feeds" || doc.Outlines[1].Outlines[0].Language != "fr" {
+		t.Error("Non-conflicting ops were not applied")
+	}
+	if doc.Outlines[0].Text != "Tech" || len(doc.Outlines) != 2 {
+		t.Error("Conflicting move was applied")
+	}
+}
+
+func TestApplyPatchUnknownIdentity(t *testing.T) {
+	if err := Apply(&OPML{}, &Patch{Identity: "nonexistent"}); err == nil {
+		t.Error("Expected error for unknown identity")
+	}
+}
@@ -0,0 +1,137 @@
+package opml
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// readCSVRecords reads a CSV export with a header row and returns its
+// records keyed by column name. Columns named in required must be present.
+func readCSVRecords(r io.Reader, required ...string) ([]map[string]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		// Some exports start with a byte order mark.
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var records []map[string]string
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		record := map[string]string{}
+		for name, i := range columns {
+			if i < len(fields) {
+				record[name] = strings.TrimSpace(fields[i])
+			}
+		}
+		records = append(records, record)
+	}
+}
+
+func platformOPML(folder string, outlines []*Outline) *OPML {
+	return &OPML{
+		Version: "2.0",
+		Title:   folder,
+		Outlines: []*Outline{
+			{Text: folder, Outlines: outlines},
+		},
+	}
+}
+
+// ParseMastodonFollowing reads the following_accounts.csv export of a
+// Mastodon account and subscribes to the public RSS feed of each account.
+func ParseMastodonFollowing(r io.Reader) (*OPML, error) {
+	records, err := readCSVRecords(r, "Account address")
+	if err != nil {
+		return nil, err
+	}
+
+	var outlines []*Outline
+	for _, record := range records {
+		address := strings.TrimPrefix(record["Account address"], "@")
+		if address == "" {
+			continue
+		}
+		at := strings.LastIndex(address, "@")
+		if at <= 0 || at == len(address)-1 {
+			return nil, fmt.Errorf("invalid account address %q", address)
+		}
+		user, host := address[:at], address[at+1:]
+
+		htmlURL := &url.URL{Scheme: "https", Host: host, Path: "/@" + user}
+		xmlURL := &url.URL{Scheme: "https", Host: host, Path: "/@" + user + ".rss"}
+		outlines = append(outlines, &Outline{
+			Text:    "@" + address,
+			Type:    "rss",
+			Title:   "@" + address,
+			XMLURL:  xmlURL,
+			HTMLURL: htmlURL,
+		})
+	}
+	return platformOPML("Mastodon", outlines), nil
+}
+
+// ParseYouTubeSubscriptions reads the subscriptions.csv file from a Google
+// Takeout export and subscribes to the video feed of each channel.
+func ParseYouTubeSubscriptions(r io.Reader) (*OPML, error) {
+	records, err := readCSVRecords(r, "Channel Id", "Channel Title")
+	if err != nil {
+		return nil, err
+	}
+
+	var outlines []*Outline
+	for _, record := range records {
+		id := record["Channel Id"]
+		if id == "" {
+			continue
+		}
+
+		htmlURL := &url.URL{Scheme: "https", Host: "www.youtube.com", Path: "/channel/" + id}
+		if record["Channel Url"] != "" {
+			u, err := url.Parse(record["Channel Url"])
+			if err != nil {
+				return nil, err
+			}
+			htmlURL = u
+		}
+		xmlURL := &url.URL{
+			Scheme:   "https",
+			Host:     "www.youtube.com",
+			Path:     "/feeds/videos.xml",
+			RawQuery: url.Values{"channel_id": {id}}.Encode(),
+		}
+		text := record["Channel Title"]
+		if text == "" {
+			text = id
+		}
+		outlines = append(outlines, &Outline{
+			Text:    text,
+			Type:    "rss",
+			Title:   text,
+			XMLURL:  xmlURL,
+			HTMLURL: htmlURL,
+		})
+	}
+	return platformOPML("YouTube", outlines), nil
+}
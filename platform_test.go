@@ -0,0 +1,88 @@
+package opml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var mastodonFollowing = &OPML{
+	Version: "2.0",
+	Title:   "Mastodon",
+	Outlines: []*Outline{
+		{
+			Text: "Mastodon",
+			Outlines: []*Outline{
+				{
+					Text:    "@Gargron@mastodon.social",
+					Type:    "rss",
+					Title:   "@Gargron@mastodon.social",
+					XMLURL:  parseURL("https://mastodon.social/@Gargron.rss"),
+					HTMLURL: parseURL("https://mastodon.social/@Gargron"),
+				},
+				{
+					Text:    "@dave@mastodon.example",
+					Type:    "rss",
+					Title:   "@dave@mastodon.example",
+					XMLURL:  parseURL("https://mastodon.example/@dave.rss"),
+					HTMLURL: parseURL("https://mastodon.example/@dave"),
+				},
+			},
+		},
+	},
+}
+
+var youTubeSubscriptions = &OPML{
+	Version: "2.0",
+	Title:   "YouTube",
+	Outlines: []*Outline{
+		{
+			Text: "YouTube",
+			Outlines: []*Outline{
+				{
+					Text:    "Google for Developers",
+					Type:    "rss",
+					Title:   "Google for Developers",
+					XMLURL:  parseURL("https://www.youtube.com/feeds/videos.xml?channel_id=UC_x5XG1OV2P6uZZ5FSM9Ttw"),
+					HTMLURL: parseURL("http://www.youtube.com/channel/UC_x5XG1OV2P6uZZ5FSM9Ttw"),
+				},
+			},
+		},
+	},
+}
+
+func TestParseMastodonFollowing(t *testing.T) {
+	got, err := ParseMastodonFollowing(openTestData("following_accounts.csv"))
+	if err != nil {
+		t.Error("Failed to parse following accounts:", err)
+	}
+
+	if !reflect.DeepEqual(mastodonFollowing, got) {
+		t.Errorf("OPML mismatch\nexpected: %#v\ngot: %#v\n", mastodonFollowing, got)
+	}
+}
+
+func TestParseMastodonFollowingInvalidAddress(t *testing.T) {
+	_, err := ParseMastodonFollowing(strings.NewReader("Account address\nnobody\n"))
+	if err == nil {
+		t.Error("Expected error for invalid account address")
+	}
+}
+
+func TestParseYouTubeSubscriptions(t *testing.T) {
+	got, err := ParseYouTubeSubscriptions(openTestData("subscriptions.csv"))
+	if err != nil {
+		t.Error("Failed to parse subscriptions:", err)
+	}
+
+	if !reflect.DeepEqual(youTubeSubscriptions, got) {
+		t.Errorf("OPML mismatch\nexpected: %#v\ngot: %#v\n", youTubeSubscriptions, got)
+	}
+}
+
+func TestParseYouTubeSubscriptionsMissingColumn(t *testing.T) {
+	_, err := ParseYouTubeSubscriptions(strings.NewReader("Channel Url\nhttp://www.youtube.com/\n"))
+	if err == nil {
+		t.Error("Expected error for missing column")
+	}
+}
@@ -0,0 +1,483 @@
+package opml
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Selector is a compiled outline selector. The syntax borrows from CSS
+// and XPath:
+//
+//	outline[type=rss][category*="/Tech"] > outline
+//	//outline[xmlUrl^="http:"]
+//	/outline:first-child/outline
+//	:depth(2), outline:empty
+//
+// A selector is a comma separated list of paths. Steps in a path are
+// joined by whitespace or // for descendants and by > or / for children.
+// A leading / anchors the first step to the top level; a leading // or no
+// slash matches at any depth.
+//
+// Each step is "outline" or "*" followed by any number of attribute and
+// pseudo-class filters. Attributes are named as in the outline element
+// and support [name], =, !=, ^=, $=, *= and ~= (whole word, or whole
+// category for "category"). A filter on "category" matches if any
+// category matches. Booleans compare as "true" or "false" and created as
+// RFC 3339. Pseudo-classes are :depth(n) with top-level outlines at depth
+// 0, :first-child, :last-child, :nth-child(n) counting from 1, :empty and
+// :parent.
+//
+// Only the attributes Outline has fields for can be selected. Parse drops
+// any other attribute of an outline element, so naming one is an error.
+type Selector struct {
+	expr  string
+	paths []selectorPath
+}
+
+type selectorPath struct {
+	rooted bool
+	steps  []selectorStep
+}
+
+type selectorStep struct {
+	// child reports whether the previous step must match the parent rather
+	// than any ancestor.
+	child   bool
+	filters []selectorFilter
+}
+
+type selectorFilter func(e selectorElement) bool
+
+type selectorElement struct {
+	outline  *Outline
+	depth    int
+	index    int
+	siblings int
+}
+
+type SelectorError struct {
+	Expr   string
+	Offset int
+	Msg    string
+}
+
+func (e *SelectorError) Error() string {
+	return fmt.Sprintf("opml: invalid selector %q at offset %d: %s", e.Expr, e.Offset, e.Msg)
+}
+
+func Compile(expr string) (*Selector, error) {
+	p := &selectorParser{expr: expr}
+	paths, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Selector{expr: expr, paths: paths}, nil
+}
+
+func MustCompile(expr string) *Selector {
+	s, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Selector) String() string {
+	return s.expr
+}
+
+// Select returns the outlines of the document matched by s, in document
+// order.
+func (s *Selector) Select(o *OPML) []Node {
+	return o.FindAll(func(n Node) bool {
+		return s.match(o.Outlines, n)
+	})
+}
+
+// Match reports whether n, a node from a walk over o, is matched by s.
+func (s *Selector) Match(o *OPML, n Node) bool {
+	return s.match(o.Outlines, n)
+}
+
+func (s *Selector) match(root []*Outline, n Node) bool {
+	chain := make([]selectorElement, len(n.Path))
+	siblings := root
+	for i, index := range n.Path {
+		outline := n.Outline
+		if i < len(n.Ancestors) {
+			outline = n.Ancestors[i]
+		}
+		chain[i] = selectorElement{outline: outline, depth: i, index: index, siblings: len(siblings)}
+		siblings = outline.Outlines
+	}
+
+	for _, p := range s.paths {
+		if p.match(chain, len(p.steps)-1, len(chain)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *selectorPath) match(chain []selectorElement, step, elem int) bool {
+	if !p.steps[step].match(chain[elem]) {
+		return false
+	}
+	if step == 0 {
+		return !p.rooted || elem == 0
+	}
+	if p.steps[step].child {
+		return elem > 0 && p.match(chain, step-1, elem-1)
+	}
+	for i := elem - 1; i >= 0; i-- {
+		if p.match(chain, step-1, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *selectorStep) match(e selectorElement) bool {
+	for _, f := range s.filters {
+		if !f(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func (o *OPML) Select(expr string) ([]Node, error) {
+	s, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return s.Select(o), nil
+}
+
+func outlineAttr(o *Outline, name string) ([]string, bool) {
+	str := func(s string) ([]string, bool) {
+		return []string{s}, s != ""
+	}
+	switch name {
+	case "text":
+		return str(o.Text)
+	case "type":
+		return str(o.Type)
+	case "iscomment":
+		return []string{strconv.FormatBool(o.IsComment)}, o.IsComment
+	case "isbreakpoint":
+		return []string{strconv.FormatBool(o.IsBreakpoint)}, o.IsBreakpoint
+	case "created":
+		if o.Created.IsZero() {
+			return []string{""}, false
+		}
+		return []string{o.Created.Format(time.RFC3339)}, true
+	case "category":
+		return o.Categories, len(o.Categories) > 0
+	case "xmlurl":
+		return urlAttr(o.XMLURL)
+	case "description":
+		return str(o.Description)
+	case "htmlurl":
+		return urlAttr(o.HTMLURL)
+	case "language":
+		return str(o.Language)
+	case "title":
+		return str(o.Title)
+	case "version":
+		return str(o.Version)
+	case "url":
+		return urlAttr(o.URL)
+	}
+	return nil, false
+}
+
+func urlAttr(u *url.URL) ([]string, bool) {
+	if u == nil {
+		return []string{""}, false
+	}
+	return []string{u.String()}, true
+}
+
+var selectorAttrs = map[string]bool{
+	"text": true, "type": true, "iscomment": true, "isbreakpoint": true,
+	"created": true, "category": true, "xmlurl": true, "description": true,
+	"htmlurl": true, "language": true, "title": true, "version": true,
+	"url": true,
+}
+
+var selectorOps = map[string]func(v, want string) bool{
+	"=":  func(v, want string) bool { return v == want },
+	"^=": strings.HasPrefix,
+	"$=": strings.HasSuffix,
+	"*=": strings.Contains,
+	"~=": func(v, want string) bool {
+		for _, w := range strings.Fields(v) {
+			if w == want {
+				return true
+			}
+		}
+		return false
+	},
+}
+
+type selectorParser struct {
+	expr string
+	pos  int
+}
+
+func (p *selectorParser) errorf(format string, args ...interface{}) error {
+	return &SelectorError{Expr: p.expr, Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *selectorParser) eof() bool {
+	return p.pos >= len(p.expr)
+}
+
+func (p *selectorParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.expr[p.pos]
+}
+
+func (p *selectorParser) skipSpace() bool {
+	start := p.pos
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\n') {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *selectorParser) consume(s string) bool {
+	if strings.HasPrefix(p.expr[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *selectorParser) parse() ([]selectorPath, error) {
+	var paths []selectorPath
+	for {
+		p.skipSpace()
+		path, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+		p.skipSpace()
+		if p.eof() {
+			return paths, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+	}
+}
+
+func (p *selectorParser) parsePath() (selectorPath, error) {
+	var path selectorPath
+	if p.consume("//") {
+		p.skipSpace()
+	} else if p.consume("/") {
+		path.rooted = true
+		p.skipSpace()
+	}
+
+	child := false
+	for {
+		step, err := p.parseStep()
+		if err != nil {
+			return path, err
+		}
+		step.child = child
+		path.steps = append(path.steps, step)
+
+		space := p.skipSpace()
+		switch {
+		case p.consume(">"):
+			child = true
+		case p.consume("//"):
+			child = false
+		case p.consume("/"):
+			child = true
+		case space && !p.eof() && p.peek() != ',':
+			child = false
+			continue
+		default:
+			return path, nil
+		}
+		p.skipSpace()
+	}
+}
+
+func (p *selectorParser) parseStep() (selectorStep, error) {
+	var step selectorStep
+	start := p.pos
+
+	if !p.consume("*") {
+		name := p.parseIdent()
+		if name != "" && name != "outline" {
+			return step, p.errorf("unknown element %q", name)
+		}
+	}
+
+	for {
+		switch p.peek() {
+		case '[':
+			f, err := p.parseAttr()
+			if err != nil {
+				return step, err
+			}
+			step.filters = append(step.filters, f)
+		case ':':
+			f, err := p.parsePseudo()
+			if err != nil {
+				return step, err
+			}
+			step.filters = append(step.filters, f)
+		default:
+			if p.pos == start {
+				return step, p.errorf("expected selector")
+			}
+			return step, nil
+		}
+	}
+}
+
+func (p *selectorParser) parseIdent() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+			p.pos++
+		} else {
+			break
+		}
+	}
+	return p.expr[start:p.pos]
+}
+
+func (p *selectorParser) parseValue() (string, error) {
+	q := p.peek()
+	if q != '"' && q != '\'' {
+		v := p.parseIdent()
+		if v == "" {
+			return "", p.errorf("expected value")
+		}
+		return v, nil
+	}
+
+	p.pos++
+	var b strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		p.pos++
+		switch {
+		case c == '\\' && !p.eof():
+			b.WriteByte(p.peek())
+			p.pos++
+		case c == q:
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *selectorParser) parseAttr() (selectorFilter, error) {
+	p.pos++
+	p.skipSpace()
+	name := strings.ToLower(p.parseIdent())
+	if !selectorAttrs[name] {
+		return nil, p.errorf("unknown attribute %q", name)
+	}
+	p.skipSpace()
+
+	if p.consume("]") {
+		return func(e selectorElement) bool {
+			_, ok := outlineAttr(e.outline, name)
+			return ok
+		}, nil
+	}
+
+	var opName string
+	for _, s := range []string{"!=", "=", "^=", "$=", "*=", "~="} {
+		if p.consume(s) {
+			opName = s
+			break
+		}
+	}
+	if opName == "" {
+		return nil, p.errorf("expected operator")
+	}
+	negate := opName == "!="
+	op := selectorOps[opName]
+	if negate || name == "category" && opName == "~=" {
+		op = selectorOps["="]
+	}
+
+	p.skipSpace()
+	want, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.consume("]") {
+		return nil, p.errorf("expected ]")
+	}
+
+	return func(e selectorElement) bool {
+		values, _ := outlineAttr(e.outline, name)
+		for _, v := range values {
+			if op(v, want) {
+				return !negate
+			}
+		}
+		return negate
+	}, nil
+}
+
+func (p *selectorParser) parsePseudo() (selectorFilter, error) {
+	p.pos++
+	name := p.parseIdent()
+
+	var arg int
+	switch name {
+	case "depth", "nth-child":
+		if !p.consume("(") {
+			return nil, p.errorf("expected (")
+		}
+		p.skipSpace()
+		n, err := strconv.Atoi(p.parseIdent())
+		if err != nil {
+			return nil, p.errorf("expected number")
+		}
+		arg = n
+		p.skipSpace()
+		if !p.consume(")") {
+			return nil, p.errorf("expected )")
+		}
+	}
+
+	switch name {
+	case "depth":
+		return func(e selectorElement) bool { return e.depth == arg }, nil
+	case "nth-child":
+		return func(e selectorElement) bool { return e.index+1 == arg }, nil
+	case "first-child":
+		return func(e selectorElement) bool { return e.index == 0 }, nil
+	case "last-child":
+		return func(e selectorElement) bool { return e.index == e.siblings-1 }, nil
+	case "empty":
+		return func(e selectorElement) bool { return len(e.outline.Outlines) == 0 }, nil
+	case "parent":
+		return func(e selectorElement) bool { return len(e.outline.Outlines) > 0 }, nil
+	}
+	return nil, p.errorf("unknown pseudo-class %q", name)
+}
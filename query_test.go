@@ -0,0 +1,76 @@
+package opml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		doc  *OPML
+		expr string
+		want []string
+	}{
+		{category, `outline[category*="/Baseball"]`, []string{"The Mets are the best team in baseball."}},
+		{category, `outline[category~="/Tourism/New York"]`, []string{"The Mets are the best team in baseball."}},
+		{category, `outline[category~="/Tourism"]`, nil},
+		{subscriptionList, `//outline[xmlUrl^="http://www.nytimes"]`, []string{"NYT > Business", "NYT > Technology"}},
+		{subscriptionList, `outline[type=rss][version!=RSS2]:first-child, outline:last-child`, []string{"Wired News"}},
+		{placesLived, `:depth(1):empty`, []string{"Florida"}},
+		{placesLived, `outline[text="Bay Area"] > outline:nth-child(2)`, []string{"Los Gatos"}},
+		{placesLived, `/outline/outline[type=include]`, []string{"Florida"}},
+		{placesLived, `/outline[text=Boston]`, nil},
+		{placesLived, `* outline[text^='New'] outline:last-child`, []string{"Metairie", "The Bronx"}},
+		{placesLived, `outline//outline[text=Madison]`, []string{"Madison"}},
+		{simpleScript, `outline[isComment=true] outline`, []string{"1/3/02; 4:54:25 PM by DW", `Change "playlist" to "radio".`, "2/12/01; 1:49:33 PM by DW", "Test upstreaming by sprinkling a few files in a nice new test folder."}},
+		{simpleScript, `[isBreakpoint]`, []string{"file.surefilepath (f)"}},
+		{states, `outline[created^="2005-07-12T23:56:3"]`, []string{"Reno", "Las Vegas", "Ely"}},
+	}
+
+	for _, test := range tests {
+		nodes, err := test.doc.Select(test.expr)
+		if err != nil {
+			t.Errorf("Failed to select %s: %v", test.expr, err)
+			continue
+		}
+
+		var got []string
+		for _, n := range nodes {
+			got = append(got, n.Outline.Text)
+		}
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("Selection mismatch for %s\nexpected: %q\ngot: %q\n", test.expr, test.want, got)
+		}
+	}
+}
+
+func TestSelectPaths(t *testing.T) {
+	nodes := MustCompile(`outline[text="Palo Alto"]`).Select(placesLived)
+	if len(nodes) != 1 {
+		t.Fatalf("Selection count mismatch\nexpected: %d\ngot: %d\n", 1, len(nodes))
+	}
+	if want := []int{0, 1, 2}; !reflect.DeepEqual(want, nodes[0].Path) {
+		t.Errorf("Path mismatch\nexpected: %v\ngot: %v\n", want, nodes[0].Path)
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"item",
+		"outline[",
+		"outline[foo=bar]",
+		"outline[text=]",
+		`outline[text="unterminated]`,
+		"outline[text~bar]",
+		":depth",
+		":depth(x)",
+		":unknown",
+		"outline >",
+		"outline ] outline",
+	} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
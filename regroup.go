@@ -0,0 +1,154 @@
+package opml
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A GroupFunc names the group an outline belongs to, or returns "" if it
+// has none.
+type GroupFunc func(o *Outline) string
+
+// GroupByHost groups feeds by the host of their XMLURL, or of their
+// HTMLURL, without a leading "www.".
+func GroupByHost(o *Outline) string {
+	u := o.XMLURL
+	if u == nil {
+		u = o.HTMLURL
+	}
+	if u == nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+func GroupByLanguage(o *Outline) string {
+	return o.Language
+}
+
+// GroupByVersion groups feeds by the format in their version attribute,
+// such as RSS2 or Atom.
+func GroupByVersion(o *Outline) string {
+	return o.Version
+}
+
+// GroupByFirstLetter groups outlines by the first letter of their text,
+// upper-cased. Text starting with anything else goes to "#".
+func GroupByFirstLetter(o *Outline) string {
+	r, _ := utf8.DecodeRuneInString(strings.TrimSpace(o.Text))
+	if r == utf8.RuneError {
+		return ""
+	}
+	if !unicode.IsLetter(r) {
+		return "#"
+	}
+	return string(unicode.ToUpper(r))
+}
+
+func GroupByCreatedYear(o *Outline) string {
+	if o.Created.IsZero() {
+		return ""
+	}
+	return strconv.Itoa(o.Created.Year())
+}
+
+type RegroupOptions struct {
+	// By lists the groupings to apply, each nesting its folders inside the
+	// folders of the one before.
+	By []GroupFunc
+	// MinSize folds groups with fewer outlines, and outlines without a
+	// group, into a folder named Other. Inside that folder, outlines left
+	// without a group by the next grouping stay loose rather than going
+	// into another Other folder.
+	MinSize int
+	// Other defaults to "Other".
+	Other string
+}
+
+// uniqueName returns name, or name followed by a number if taken has it,
+// and adds the result to taken.
+func uniqueName(name string, taken map[string]bool) string {
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%s %d", name, i)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// regroup groups outlines into new folders, whose names are kept distinct
+// from those in taken. Outlines without a group stay loose if inOther is
+// set.
+func regroup(outlines []*Outline, by []GroupFunc, opts *RegroupOptions, taken map[string]bool, inOther bool) []*Outline {
+	if len(by) == 0 {
+		return outlines
+	}
+
+	groups := map[string][]*Outline{}
+	names := make([]string, len(outlines))
+	for i, o := range outlines {
+		names[i] = by[0](o)
+		groups[names[i]] = append(groups[names[i]], o)
+	}
+
+	other := opts.Other
+	if other == "" {
+		other = "Other"
+	}
+	var others []*Outline
+	for i, o := range outlines {
+		if names[i] == "" || len(groups[names[i]]) < opts.MinSize {
+			delete(groups, names[i])
+			others = append(others, o)
+		}
+	}
+
+	sorted := make([]string, 0, len(groups))
+	for name := range groups {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var folders []*Outline
+	for _, name := range sorted {
+		folders = append(folders, &Outline{Text: uniqueName(name, taken), Outlines: regroup(groups[name], by[1:], opts, map[string]bool{}, false)})
+	}
+	if len(others) > 0 {
+		if inOther {
+			return append(folders, regroup(others, by[1:], opts, taken, true)...)
+		}
+		folders = append(folders, &Outline{Text: uniqueName(other, taken), Outlines: regroup(others, by[1:], opts, map[string]bool{}, true)})
+	}
+	return folders
+}
+
+// Regroup moves the top-level outlines that are not folders into new
+// folders named by the groupings, sorted by name and placed after the
+// existing folders. New folders named like an existing one get a number
+// after their name. Outlines keep their order within a group.
+func (o *OPML) Regroup(opts *RegroupOptions) {
+	if opts == nil || len(opts.By) == 0 {
+		return
+	}
+
+	editExpansion(o, func(expanded map[*Outline]bool) (Edit, error) {
+		var folders, items []*Outline
+		taken := map[string]bool{}
+		for _, outline := range o.Outlines {
+			if isFolder(outline) {
+				folders = append(folders, outline)
+				taken[outline.Text] = true
+			} else {
+				items = append(items, outline)
+			}
+		}
+		if len(items) > 0 {
+			o.Outlines = append(folders, regroup(items, opts.By, opts, taken, false)...)
+		}
+		return nil, nil
+	})
+}
@@ -0,0 +1,97 @@
+package opml
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func flatDocument() *OPML {
+	year := func(y int) time.Time { return time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC) }
+	return &OPML{Outlines: []*Outline{
+		{Text: "Folder", Outlines: []*Outline{{Text: "Inside"}}},
+		{Text: "Go Blog", Type: "rss", XMLURL: parseURL("https://go.dev/blog/feed.atom"), Language: "en", Version: "Atom", Created: year(2019)},
+		{Text: "Scripting News", Type: "rss", XMLURL: parseURL("http://www.scripting.com/rss.xml"), Language: "en", Version: "RSS2", Created: year(2005)},
+		{Text: "Le Monde", Type: "rss", XMLURL: parseURL("https://www.lemonde.fr/rss/une.xml"), Language: "fr", Version: "RSS2", Created: year(2019)},
+		{Text: "Go Releases", Type: "rss", XMLURL: parseURL("https://go.dev/releases.atom"), Language: "en", Version: "Atom"},
+		{Text: "42", Type: "rss", HTMLURL: parseURL("http://42.example.com/")},
+	}}
+}
+
+func TestGroupFuncs(t *testing.T) {
+	doc := flatDocument()
+	tests := []struct {
+		name string
+		fn   GroupFunc
+		want []string
+	}{
+		{"host", GroupByHost, []string{"go.dev", "scripting.com", "lemonde.fr", "go.dev", "42.example.com"}},
+		{"language", GroupByLanguage, []string{"en", "en", "fr", "en", ""}},
+		{"version", GroupByVersion, []string{"Atom", "RSS2", "RSS2", "Atom", ""}},
+		{"first letter", GroupByFirstLetter, []string{"G", "S", "L", "G", "#"}},
+		{"created year", GroupByCreatedYear, []string{"2019", "2005", "2019", "", ""}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, o := range doc.Outlines[1:] {
+			got = append(got, test.fn(o))
+		}
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("Groups mismatch grouping by %s\nexpected: %v\ngot: %v\n", test.name, test.want, got)
+		}
+	}
+}
+
+func TestRegroup(t *testing.T) {
+	doc := flatDocument()
+	doc.Regroup(&RegroupOptions{By: []GroupFunc{GroupByLanguage, GroupByVersion}})
+
+	want := []interface{}{
+		"Folder", []interface{}{"Inside"},
+		"en", []interface{}{
+			"Atom", []interface{}{"Go Blog", "Go Releases"},
+			"RSS2", []interface{}{"Scripting News"},
+		},
+		"fr", []interface{}{"RSS2", []interface{}{"Le Monde"}},
+		"Other", []interface{}{"42"},
+	}
+	if got := outlineTexts(doc.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+}
+
+func TestRegroupMinSize(t *testing.T) {
+	doc := flatDocument()
+	doc.ExpansionState = []int{1}
+	doc.Regroup(&RegroupOptions{By: []GroupFunc{GroupByHost}, MinSize: 2, Other: "Misc"})
+
+	want := []interface{}{
+		"Folder", []interface{}{"Inside"},
+		"go.dev", []interface{}{"Go Blog", "Go Releases"},
+		"Misc", []interface{}{"Scripting News", "Le Monde", "42"},
+	}
+	if got := outlineTexts(doc.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+	if state := []int{1}; !reflect.DeepEqual(state, doc.ExpansionState) {
+		t.Errorf("ExpansionState mismatch\nexpected: %v\ngot: %v\n", state, doc.ExpansionState)
+	}
+}
+
+func TestRegroupNameCollision(t *testing.T) {
+	doc := flatDocument()
+	doc.Outlines[0].Text = "Other"
+	doc.Outlines = append(doc.Outlines, &Outline{Text: "en", Outlines: []*Outline{{Text: "Existing"}}})
+	doc.Regroup(&RegroupOptions{By: []GroupFunc{GroupByLanguage}})
+
+	want := []interface{}{
+		"Other", []interface{}{"Inside"},
+		"en", []interface{}{"Existing"},
+		"en 2", []interface{}{"Go Blog", "Scripting News", "Go Releases"},
+		"fr", []interface{}{"Le Monde"},
+		"Other 2", []interface{}{"42"},
+	}
+	if got := outlineTexts(doc.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+}
@@ -0,0 +1,303 @@
+package opml
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A URLRewriter rewrites a URL, reporting whether it applied. It must not
+// modify u.
+type URLRewriter interface {
+	RewriteURL(u *url.URL) (*url.URL, bool, error)
+}
+
+// A RegexpRewriter replaces matches of Pattern in the whole URL with
+// Replacement, which may refer to groups as $1 or ${name}.
+type RegexpRewriter struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+func (r *RegexpRewriter) RewriteURL(u *url.URL) (*url.URL, bool, error) {
+	s := u.String()
+	if !r.Pattern.MatchString(s) {
+		return u, false, nil
+	}
+	rewritten, err := url.Parse(r.Pattern.ReplaceAllString(s, r.Replacement))
+	if err != nil {
+		return u, false, err
+	}
+	return rewritten, true, nil
+}
+
+// A HostRewriter matches the scheme, host and path of a URL and replaces
+// them, keeping the user, query and fragment. Host and Path are patterns
+// in which * matches any text; an empty pattern matches anything. ToHost
+// and ToPath may refer to the text matched by each * as $1, $2 and so on,
+// numbered from the host to the path. Empty replacements keep the old
+// value. The patterns are compiled once, when the rules are parsed or on
+// first use, so changing them afterwards has no effect.
+type HostRewriter struct {
+	Scheme   string
+	Host     string
+	Path     string
+	ToScheme string
+	ToHost   string
+	ToPath   string
+
+	once     sync.Once
+	hostGlob *regexp.Regexp
+	pathGlob *regexp.Regexp
+}
+
+// compileGlob compiles a pattern in which * matches any text, capturing
+// the matched texts. An empty pattern compiles to nil, which matches
+// anything.
+func compileGlob(pattern string, fold bool) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	expr := "^" + strings.Join(parts, "(.*?)") + "$"
+	if fold {
+		expr = "(?i)" + expr
+	}
+	return regexp.MustCompile(expr)
+}
+
+// globMatch matches s against a glob compiled by compileGlob, and returns
+// the matched texts.
+func globMatch(glob *regexp.Regexp, s string) ([]string, bool) {
+	if glob == nil {
+		return nil, true
+	}
+	m := glob.FindStringSubmatch(s)
+	if m == nil {
+		return nil, false
+	}
+	return m[1:], true
+}
+
+var templateRef = regexp.MustCompile(`\$(\d+)|\$\{(\d+)\}`)
+
+func expandTemplate(template string, captures []string) string {
+	return templateRef.ReplaceAllStringFunc(template, func(ref string) string {
+		m := templateRef.FindStringSubmatch(ref)
+		n, _ := strconv.Atoi(m[1] + m[2])
+		if n < 1 || n > len(captures) {
+			return ""
+		}
+		return captures[n-1]
+	})
+}
+
+func (r *HostRewriter) compile() {
+	r.once.Do(func() {
+		r.hostGlob = compileGlob(r.Host, true)
+		r.pathGlob = compileGlob(r.Path, false)
+	})
+}
+
+func (r *HostRewriter) RewriteURL(u *url.URL) (*url.URL, bool, error) {
+	if r.Scheme != "" && !strings.EqualFold(r.Scheme, u.Scheme) {
+		return u, false, nil
+	}
+	r.compile()
+	hostCaptures, ok := globMatch(r.hostGlob, u.Host)
+	if !ok {
+		return u, false, nil
+	}
+	pathCaptures, ok := globMatch(r.pathGlob, u.Path)
+	if !ok {
+		return u, false, nil
+	}
+	captures := append(hostCaptures, pathCaptures...)
+
+	rewritten := cloneURL(u)
+	if r.ToScheme != "" {
+		rewritten.Scheme = r.ToScheme
+	}
+	if r.ToHost != "" {
+		rewritten.Host = expandTemplate(r.ToHost, captures)
+	}
+	if r.ToPath != "" {
+		rewritten.Path = expandTemplate(r.ToPath, captures)
+		rewritten.RawPath = ""
+	}
+	return rewritten, true, nil
+}
+
+// A RewriteRule applies a URLRewriter to the URL attributes named in
+// Fields: "xmlUrl", "htmlUrl" and "url". Empty Fields means all of them.
+type RewriteRule struct {
+	URLRewriter
+	Fields []string
+}
+
+func (r RewriteRule) appliesTo(field string) bool {
+	if len(r.Fields) == 0 {
+		return true
+	}
+	for _, f := range r.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// A URLChange is a URL rewritten by RewriteURLs.
+type URLChange struct {
+	Outline *Outline
+	Path    []int
+	Field   string
+	Old     *url.URL
+	New     *url.URL
+}
+
+func (c URLChange) String() string {
+	return fmt.Sprintf("%s %s %s: %s -> %s", formatPath(c.Path), outlineLabel(c.Outline), c.Field, c.Old, c.New)
+}
+
+// RewriteURLs applies rules in order to the URL attributes of every
+// outline, each rule seeing the result of the ones before it, and reports
+// the changes. With dryRun the document is left unchanged. If a rule
+// fails, no URL is changed.
+func RewriteURLs(doc *OPML, rules []RewriteRule, dryRun bool) ([]URLChange, error) {
+	var changes []URLChange
+	err := doc.Walk(PreOrder, func(n Node) error {
+		for _, field := range []struct {
+			name string
+			url  *url.URL
+		}{
+			{"xmlUrl", n.Outline.XMLURL},
+			{"htmlUrl", n.Outline.HTMLURL},
+			{"url", n.Outline.URL},
+		} {
+			if field.url == nil {
+				continue
+			}
+			u := field.url
+			for _, rule := range rules {
+				if !rule.appliesTo(field.name) {
+					continue
+				}
+				rewritten, _, err := rule.RewriteURL(u)
+				if err != nil {
+					return fmt.Errorf("opml: rewriting %s of %s: %v", field.name, formatPath(n.Path), err)
+				}
+				u = rewritten
+			}
+			if u.String() != field.url.String() {
+				changes = append(changes, URLChange{Outline: n.Outline, Path: n.Path, Field: field.name, Old: field.url, New: u})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !dryRun {
+		for _, c := range changes {
+			switch c.Field {
+			case "xmlUrl":
+				c.Outline.XMLURL = c.New
+			case "htmlUrl":
+				c.Outline.HTMLURL = c.New
+			case "url":
+				c.Outline.URL = c.New
+			}
+		}
+	}
+	return changes, nil
+}
+
+// splitURLPattern splits a pattern of the form [scheme://]host[/path] of
+// a host rule.
+func splitURLPattern(pattern string) (scheme, host, path string) {
+	if i := strings.Index(pattern, "://"); i >= 0 {
+		scheme, pattern = pattern[:i], pattern[i+3:]
+	}
+	if scheme == "*" {
+		scheme = ""
+	}
+	if i := strings.Index(pattern, "/"); i >= 0 {
+		return scheme, pattern[:i], pattern[i:]
+	}
+	return scheme, pattern, ""
+}
+
+// ParseRewriteRules reads rules, one per line:
+//
+//	regexp PATTERN REPLACEMENT [FIELDS]
+//	host FROM TO [FIELDS]
+//
+// FROM and TO have the form [scheme://]host[/path], with * in FROM
+// matching any text and $1, $2 in TO referring to it. FIELDS is a comma
+// separated list of the attributes to rewrite. Blank lines and lines
+// starting with # are ignored. Patterns cannot contain spaces.
+func ParseRewriteRules(r io.Reader) ([]RewriteRule, error) {
+	var rules []RewriteRule
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 3 && len(fields) != 4 {
+			return nil, fmt.Errorf("opml: rewrite rules line %d: expected a kind, a pattern, a replacement and optional fields", line)
+		}
+
+		var rule RewriteRule
+		switch fields[0] {
+		case "regexp":
+			pattern, err := regexp.Compile(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("opml: rewrite rules line %d: %v", line, err)
+			}
+			rule.URLRewriter = &RegexpRewriter{Pattern: pattern, Replacement: fields[2]}
+		case "host":
+			h := &HostRewriter{}
+			h.Scheme, h.Host, h.Path = splitURLPattern(fields[1])
+			h.ToScheme, h.ToHost, h.ToPath = splitURLPattern(fields[2])
+			h.compile()
+			rule.URLRewriter = h
+		default:
+			return nil, fmt.Errorf("opml: rewrite rules line %d: unknown rule kind %q", line, fields[0])
+		}
+		if len(fields) == 4 {
+			rule.Fields = strings.Split(fields[3], ",")
+			for _, f := range rule.Fields {
+				if f != "xmlUrl" && f != "htmlUrl" && f != "url" {
+					return nil, fmt.Errorf("opml: rewrite rules line %d: unknown field %q", line, f)
+				}
+			}
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func LoadRewriteRules(path string) ([]RewriteRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseRewriteRules(f)
+}
@@ -0,0 +1,91 @@
+package opml
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func rewriteDocument() *OPML {
+	return &OPML{Outlines: []*Outline{
+		{Text: "Scripting News", XMLURL: parseURL("http://www.scripting.com/rss.xml"), HTMLURL: parseURL("http://www.scripting.com/")},
+		{Text: "Blog", XMLURL: parseURL("https://alice.blogspot.com/feeds/posts/default?alt=rss#top")},
+		{Text: "Old", HTMLURL: parseURL("http://old.example.com/blog"), URL: parseURL("http://old.example.com/index.opml")},
+	}}
+}
+
+func TestRewriteURLs(t *testing.T) {
+	rules, err := LoadRewriteRules("testdata/rewrite.rules")
+	if err != nil {
+		t.Fatal("Failed to load rules:", err)
+	}
+
+	doc := rewriteDocument()
+	changes, err := RewriteURLs(doc, rules, true)
+	if err != nil {
+		t.Fatal("Failed to rewrite:", err)
+	}
+
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		`0 "Scripting News" xmlUrl: http://www.scripting.com/rss.xml -> https://www.scripting.com/rss.xml`,
+		`1 "Blog" xmlUrl: https://alice.blogspot.com/feeds/posts/default?alt=rss#top -> https://alice.example.org/feeds/posts/default?alt=rss#top`,
+		`2 "Old" htmlUrl: http://old.example.com/blog -> http://new.example.com/blog`,
+		`2 "Old" url: http://old.example.com/index.opml -> http://new.example.com/index.opml`,
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Changes mismatch\nexpected: %s\ngot: %s\n", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+	if u := doc.Outlines[0].XMLURL.String(); u != "http://www.scripting.com/rss.xml" {
+		t.Errorf("XMLURL mismatch after dry run\nexpected: %s\ngot: %s\n", "http://www.scripting.com/rss.xml", u)
+	}
+
+	if _, err := RewriteURLs(doc, rules, false); err != nil {
+		t.Fatal("Failed to rewrite:", err)
+	}
+	for _, c := range changes {
+		var u string
+		switch c.Field {
+		case "xmlUrl":
+			u = doc.OutlineAt(c.Path...).XMLURL.String()
+		case "htmlUrl":
+			u = doc.OutlineAt(c.Path...).HTMLURL.String()
+		case "url":
+			u = doc.OutlineAt(c.Path...).URL.String()
+		}
+		if u != c.New.String() {
+			t.Errorf("URL mismatch\nexpected: %s\ngot: %s\n", c.New, u)
+		}
+	}
+	if u := doc.Outlines[0].HTMLURL.String(); u != "http://www.scripting.com/" {
+		t.Errorf("HTMLURL mismatch\nexpected: %s\ngot: %s\n", "http://www.scripting.com/", u)
+	}
+}
+
+func TestRewriteURLsError(t *testing.T) {
+	doc := rewriteDocument()
+	rules := []RewriteRule{{URLRewriter: &RegexpRewriter{Pattern: regexp.MustCompile(`^http://www`), Replacement: "%zz"}}}
+	if _, err := RewriteURLs(doc, rules, false); err == nil {
+		t.Error("Expected an error for an invalid rewritten URL")
+	}
+	if u := doc.Outlines[0].XMLURL.String(); u != "http://www.scripting.com/rss.xml" {
+		t.Errorf("XMLURL mismatch after failed rewrite\nexpected: %s\ngot: %s\n", "http://www.scripting.com/rss.xml", u)
+	}
+}
+
+func TestParseRewriteRulesErrors(t *testing.T) {
+	for _, rules := range []string{
+		"regexp ( x",
+		"glob a b",
+		"host a",
+		"host a b xmlURL",
+	} {
+		if _, err := ParseRewriteRules(strings.NewReader(rules)); err == nil {
+			t.Errorf("Expected an error for %q", rules)
+		}
+	}
+}
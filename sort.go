@@ -0,0 +1,146 @@
+package opml
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+type FolderPlacement int
+
+const (
+	FoldersMixed FolderPlacement = iota
+	FoldersFirst
+	FoldersLast
+)
+
+type SortOptions struct {
+	// By names the attribute to sort by as in OPML, such as "text",
+	// "title" or "created". Defaults to "text". Outlines without it sort
+	// last. Sort returns an error for an attribute outlines do not have.
+	By      string
+	Reverse bool
+	// Folders places outlines with children before or after the others.
+	Folders FolderPlacement
+	// MinDepth and MaxDepth limit sorting to the levels between them,
+	// counting the top level as 1. Zero means no limit.
+	MinDepth int
+	MaxDepth int
+	// Language selects the collation for text. Numbers in text compare by
+	// value, so "Feed 9" sorts before "Feed 10".
+	Language language.Tag
+}
+
+func (opts *SortOptions) inDepth(level int) bool {
+	return (opts.MinDepth <= 0 || level >= opts.MinDepth) &&
+		(opts.MaxDepth <= 0 || level <= opts.MaxDepth)
+}
+
+type sorter struct {
+	opts     *SortOptions
+	by       string
+	collator *collate.Collator
+}
+
+func (s *sorter) folderRank(o *Outline) int {
+	folder := len(o.Outlines) > 0
+	switch s.opts.Folders {
+	case FoldersFirst:
+		return boolRank(!folder)
+	case FoldersLast:
+		return boolRank(folder)
+	}
+	return 0
+}
+
+// compare returns the order of a and b by the sort attribute, and whether
+// both have it.
+func (s *sorter) compare(a, b *Outline) (int, bool) {
+	if s.by == "created" {
+		if a.Created.IsZero() || b.Created.IsZero() {
+			return boolRank(a.Created.IsZero()) - boolRank(b.Created.IsZero()), false
+		}
+		switch {
+		case a.Created.Before(b.Created):
+			return -1, true
+		case a.Created.After(b.Created):
+			return 1, true
+		}
+		return 0, true
+	}
+
+	av, bv := outlineAttrString(a, s.by), outlineAttrString(b, s.by)
+	if av == "" || bv == "" {
+		return boolRank(av == "") - boolRank(bv == ""), false
+	}
+	return s.collator.CompareString(av, bv), true
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (s *sorter) less(a, b *Outline) bool {
+	if ra, rb := s.folderRank(a), s.folderRank(b); ra != rb {
+		return ra < rb
+	}
+	c, both := s.compare(a, b)
+	if both && s.opts.Reverse {
+		c = -c
+	}
+	return c < 0
+}
+
+func (s *sorter) sort(os []*Outline, level int) {
+	if s.opts.inDepth(level) {
+		sort.SliceStable(os, func(i, j int) bool { return s.less(os[i], os[j]) })
+	}
+	if s.opts.MaxDepth > 0 && level >= s.opts.MaxDepth {
+		return
+	}
+	for _, o := range os {
+		s.sort(o.Outlines, level+1)
+	}
+}
+
+// Sort sorts the outlines of the document recursively, keeping the order
+// of outlines that compare equal and the expanded outlines.
+func (o *OPML) Sort(opts *SortOptions) error {
+	if opts == nil {
+		opts = &SortOptions{}
+	}
+	s := &sorter{
+		opts:     opts,
+		by:       strings.ToLower(opts.By),
+		collator: collate.New(opts.Language, collate.Numeric),
+	}
+	if s.by == "" {
+		s.by = "text"
+	}
+	if !isOutlineAttr(s.by) {
+		return fmt.Errorf("opml: unknown sort attribute %q", opts.By)
+	}
+
+	editExpansion(o, func(expanded map[*Outline]bool) (Edit, error) {
+		s.sort(o.Outlines, 1)
+		return nil, nil
+	})
+	return nil
+}
+
+// isOutlineAttr reports whether name, in lower case, is an attribute of
+// the outline element.
+func isOutlineAttr(name string) bool {
+	for _, attr := range outlineAttrNames {
+		if strings.ToLower(attr) == name {
+			return true
+		}
+	}
+	return false
+}
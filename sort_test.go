@@ -0,0 +1,106 @@
+package opml
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+func sortDocument() *OPML {
+	return &OPML{
+		ExpansionState: []int{2},
+		Outlines: []*Outline{
+			{Text: "Feed 10"},
+			{Text: "Tech", Outlines: []*Outline{
+				{Text: "b"},
+				{Text: "A"},
+			}},
+			{Text: "feed 9"},
+			{Text: "Älpler"},
+			{Text: "News", Outlines: []*Outline{
+				{Text: "z"},
+				{Text: "y"},
+			}},
+		},
+	}
+}
+
+func TestSort(t *testing.T) {
+	doc := sortDocument()
+	tech := doc.Outlines[1]
+	if err := doc.Sort(nil); err != nil {
+		t.Fatal("Failed to sort:", err)
+	}
+
+	want := []interface{}{"Älpler", "feed 9", "Feed 10", "News", []interface{}{"y", "z"}, "Tech", []interface{}{"A", "b"}}
+	if got := outlineTexts(doc.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+	if state := []int{5}; !reflect.DeepEqual(state, doc.ExpansionState) || doc.Outlines[4] != tech {
+		t.Errorf("ExpansionState mismatch\nexpected: %v\ngot: %v\n", state, doc.ExpansionState)
+	}
+}
+
+func TestSortOptions(t *testing.T) {
+	tests := []struct {
+		opts *SortOptions
+		want []interface{}
+	}{
+		{
+			&SortOptions{Folders: FoldersFirst, MaxDepth: 1},
+			[]interface{}{"News", []interface{}{"z", "y"}, "Tech", []interface{}{"b", "A"}, "Älpler", "feed 9", "Feed 10"},
+		},
+		{
+			&SortOptions{Folders: FoldersLast, Reverse: true},
+			[]interface{}{"Feed 10", "feed 9", "Älpler", "Tech", []interface{}{"b", "A"}, "News", []interface{}{"z", "y"}},
+		},
+		{
+			&SortOptions{MinDepth: 2},
+			[]interface{}{"Feed 10", "Tech", []interface{}{"A", "b"}, "feed 9", "Älpler", "News", []interface{}{"y", "z"}},
+		},
+		{
+			&SortOptions{Language: language.Swedish},
+			[]interface{}{"feed 9", "Feed 10", "News", []interface{}{"y", "z"}, "Tech", []interface{}{"A", "b"}, "Älpler"},
+		},
+	}
+	for _, test := range tests {
+		doc := sortDocument()
+		if err := doc.Sort(test.opts); err != nil {
+			t.Fatal("Failed to sort:", err)
+		}
+		if got := outlineTexts(doc.Outlines); !reflect.DeepEqual(test.want, got) {
+			t.Errorf("Outlines mismatch with %+v\nexpected: %v\ngot: %v\n", test.opts, test.want, got)
+		}
+	}
+}
+
+func TestSortByCreated(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC) }
+	doc := &OPML{Outlines: []*Outline{
+		{Text: "none"},
+		{Text: "third", Created: day(3)},
+		{Text: "first", Created: day(1)},
+		{Text: "second", Created: day(2)},
+		{Text: "also none"},
+	}}
+	if err := doc.Sort(&SortOptions{By: "created"}); err != nil {
+		t.Fatal("Failed to sort:", err)
+	}
+
+	want := []interface{}{"first", "second", "third", "none", "also none"}
+	if got := outlineTexts(doc.Outlines); !reflect.DeepEqual(want, got) {
+		t.Errorf("Outlines mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+}
+
+func TestSortUnknownAttribute(t *testing.T) {
+	doc := sortDocument()
+	if err := doc.Sort(&SortOptions{By: "xmlurl"}); err != nil {
+		t.Error("Failed to sort by xmlUrl:", err)
+	}
+	if err := doc.Sort(&SortOptions{By: "name"}); err == nil {
+		t.Error("Expected an error for an unknown attribute")
+	}
+}
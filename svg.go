@@ -0,0 +1,288 @@
+package opml
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+)
+
+type SVGLayout int
+
+const (
+	SVGTree SVGLayout = iota
+	SVGTreemap
+	SVGSunburst
+)
+
+type SVGOptions struct {
+	DiagramOptions
+	Layout SVGLayout
+	// Width and Height default to 800 by 600 pixels when zero.
+	Width  int
+	Height int
+}
+
+const (
+	svgNodeRadius   = 4
+	svgFontSize     = 12
+	svgTreemapInset = 4
+	svgTreemapLabel = 16
+)
+
+type svgWriter struct {
+	w      *bufio.Writer
+	opts   *SVGOptions
+	width  float64
+	height float64
+}
+
+// svgNode is an outline positioned by one of the layouts. The root node
+// stands for the document itself and has no outline.
+type svgNode struct {
+	outline  *Outline
+	label    string
+	depth    int
+	weight   int
+	children []*svgNode
+	x, y     float64
+}
+
+func newSVGNode(o *Outline, label string, children []*Outline, depth int, opts *SVGOptions) *svgNode {
+	n := &svgNode{outline: o, label: label, depth: depth, weight: 1}
+	if !opts.inDepth(depth + 1) {
+		return n
+	}
+	for _, c := range children {
+		child := newSVGNode(c, opts.label(c), c.Outlines, depth+1, opts)
+		n.children = append(n.children, child)
+		n.weight += child.weight
+	}
+	return n
+}
+
+// childWeight is the number of descendants, which is what the treemap and
+// sunburst layouts divide among the children.
+func (n *svgNode) childWeight() int {
+	return n.weight - 1
+}
+
+func (n *svgNode) link() string {
+	if n.outline == nil {
+		return ""
+	}
+	if n.outline.HTMLURL != nil {
+		return n.outline.HTMLURL.String()
+	}
+	if n.outline.URL != nil {
+		return n.outline.URL.String()
+	}
+	return ""
+}
+
+func (sw *svgWriter) open(n *svgNode) {
+	if l := n.link(); l != "" {
+		fmt.Fprintf(sw.w, `<a href="%s">`, html.EscapeString(l))
+	}
+	fmt.Fprint(sw.w, "<g>")
+	fmt.Fprintf(sw.w, "<title>%s</title>", html.EscapeString(n.label))
+}
+
+func (sw *svgWriter) close(n *svgNode) {
+	fmt.Fprint(sw.w, "</g>")
+	if n.link() != "" {
+		fmt.Fprint(sw.w, "</a>")
+	}
+	fmt.Fprintln(sw.w)
+}
+
+func (sw *svgWriter) text(x, y float64, anchor, label string) {
+	fmt.Fprintf(sw.w, `<text x="%.2f" y="%.2f" font-size="%d" text-anchor="%s">%s</text>`, x, y, svgFontSize, anchor, html.EscapeString(label))
+}
+
+// tree places leaves on consecutive columns and centers every parent over
+// its children, one row per depth.
+func (sw *svgWriter) tree(root *svgNode) {
+	var leaves, depth int
+	var place func(*svgNode)
+	place = func(n *svgNode) {
+		if n.depth > depth {
+			depth = n.depth
+		}
+		if len(n.children) == 0 {
+			n.x = float64(leaves)
+			leaves++
+			return
+		}
+		for _, c := range n.children {
+			place(c)
+		}
+		n.x = (n.children[0].x + n.children[len(n.children)-1].x) / 2
+	}
+	place(root)
+
+	colWidth := sw.width / float64(leaves)
+	rowHeight := sw.height / float64(depth+1)
+	var scale func(*svgNode)
+	scale = func(n *svgNode) {
+		n.x = (n.x + 0.5) * colWidth
+		n.y = (float64(n.depth) + 0.5) * rowHeight
+		for _, c := range n.children {
+			scale(c)
+		}
+	}
+	scale(root)
+
+	var edges func(*svgNode)
+	edges = func(n *svgNode) {
+		for _, c := range n.children {
+			fmt.Fprintf(sw.w, `<path d="M%.2f,%.2fC%.2f,%.2f %.2f,%.2f %.2f,%.2f" fill="none" stroke="#999"/>`+"\n",
+				n.x, n.y, n.x, (n.y+c.y)/2, c.x, (n.y+c.y)/2, c.x, c.y)
+			edges(c)
+		}
+	}
+	edges(root)
+
+	var nodes func(*svgNode)
+	nodes = func(n *svgNode) {
+		sw.open(n)
+		fmt.Fprintf(sw.w, `<circle cx="%.2f" cy="%.2f" r="%d" fill="#4682b4"/>`, n.x, n.y, svgNodeRadius)
+		sw.text(n.x, n.y-svgNodeRadius*2, "middle", n.label)
+		sw.close(n)
+		for _, c := range n.children {
+			nodes(c)
+		}
+	}
+	nodes(root)
+}
+
+// treemap uses a slice-and-dice layout, alternating the split direction
+// with depth.
+func (sw *svgWriter) treemap(n *svgNode, x, y, w, h float64) {
+	sw.open(n)
+	fmt.Fprintf(sw.w, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s" stroke="#fff"/>`, x, y, w, h, svgColor(n.depth))
+	sw.text(x+svgTreemapInset, y+svgFontSize, "start", n.label)
+	sw.close(n)
+
+	if len(n.children) == 0 {
+		return
+	}
+	x += svgTreemapInset
+	y += svgTreemapLabel
+	w -= svgTreemapInset * 2
+	h -= svgTreemapLabel + svgTreemapInset
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	total := float64(n.childWeight())
+	offset := 0.0
+	for _, c := range n.children {
+		share := float64(c.weight) / total
+		if n.depth%2 == 0 {
+			sw.treemap(c, x+offset*w, y, share*w, h)
+		} else {
+			sw.treemap(c, x, y+offset*h, w, share*h)
+		}
+		offset += share
+	}
+}
+
+// sunburst draws one ring per depth, with each outline's angle proportional
+// to its weight within the parent's arc.
+func (sw *svgWriter) sunburst(n *svgNode, start, end, ring float64) {
+	cx, cy := sw.width/2, sw.height/2
+	if n.depth == 0 {
+		sw.open(n)
+		fmt.Fprintf(sw.w, `<circle cx="%.2f" cy="%.2f" r="%.2f" fill="%s" stroke="#fff"/>`, cx, cy, ring, svgColor(0))
+		sw.text(cx, cy, "middle", n.label)
+		sw.close(n)
+	} else {
+		inner := ring * float64(n.depth)
+		outer := inner + ring
+		sw.open(n)
+		fmt.Fprintf(sw.w, `<path d="%s" fill="%s" stroke="#fff"/>`, svgArc(cx, cy, inner, outer, start, end), svgColor(n.depth))
+		mid := (start + end) / 2
+		r := (inner + outer) / 2
+		sw.text(cx+r*math.Cos(mid), cy+r*math.Sin(mid), "middle", n.label)
+		sw.close(n)
+	}
+
+	total := float64(n.childWeight())
+	angle := start
+	for _, c := range n.children {
+		span := (end - start) * float64(c.weight) / total
+		sw.sunburst(c, angle, angle+span, ring)
+		angle += span
+	}
+}
+
+func svgArc(cx, cy, inner, outer, start, end float64) string {
+	// A full circle cannot be drawn with a single arc command.
+	if end-start >= 2*math.Pi {
+		end = start + 2*math.Pi - 1e-6
+	}
+	large := 0
+	if end-start > math.Pi {
+		large = 1
+	}
+	point := func(r, a float64) (float64, float64) {
+		return cx + r*math.Cos(a), cy + r*math.Sin(a)
+	}
+	x0, y0 := point(outer, start)
+	x1, y1 := point(outer, end)
+	x2, y2 := point(inner, end)
+	x3, y3 := point(inner, start)
+	return fmt.Sprintf("M%.2f,%.2fA%.2f,%.2f 0 %d 1 %.2f,%.2fL%.2f,%.2fA%.2f,%.2f 0 %d 0 %.2f,%.2fZ",
+		x0, y0, outer, outer, large, x1, y1, x2, y2, inner, inner, large, x3, y3)
+}
+
+var svgPalette = [...]string{"#4682b4", "#6a9fcb", "#8fbbe0", "#b4d4ee", "#d6e8f7"}
+
+func svgColor(depth int) string {
+	if depth >= len(svgPalette) {
+		depth = len(svgPalette) - 1
+	}
+	return svgPalette[depth]
+}
+
+func (n *svgNode) height() int {
+	h := 0
+	for _, c := range n.children {
+		if ch := c.height(); ch > h {
+			h = ch
+		}
+	}
+	return h + 1
+}
+
+func RenderSVG(w io.Writer, opml *OPML, opts *SVGOptions) error {
+	if opts == nil {
+		opts = &SVGOptions{}
+	}
+
+	sw := &svgWriter{w: bufio.NewWriter(w), opts: opts, width: 800, height: 600}
+	if opts.Width > 0 {
+		sw.width = float64(opts.Width)
+	}
+	if opts.Height > 0 {
+		sw.height = float64(opts.Height)
+	}
+
+	root := newSVGNode(nil, opml.Title, opml.Outlines, 0, opts)
+
+	fmt.Fprintf(sw.w, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g" font-family="sans-serif">`+"\n",
+		sw.width, sw.height, sw.width, sw.height)
+	switch opts.Layout {
+	case SVGTreemap:
+		sw.treemap(root, 0, 0, sw.width, sw.height)
+	case SVGSunburst:
+		ring := math.Min(sw.width, sw.height) / 2 / float64(root.height())
+		sw.sunburst(root, -math.Pi/2, 3*math.Pi/2, ring)
+	default:
+		sw.tree(root)
+	}
+	fmt.Fprintln(sw.w, "</svg>")
+	return sw.w.Flush()
+}
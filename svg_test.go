@@ -0,0 +1,63 @@
+package opml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+)
+
+func TestRenderSVG(t *testing.T) {
+	for _, layout := range []SVGLayout{SVGTree, SVGTreemap, SVGSunburst} {
+		var buf bytes.Buffer
+		err := RenderSVG(&buf, subscriptionList, &SVGOptions{Layout: layout})
+		if err != nil {
+			t.Error("Failed to render SVG:", err)
+		}
+
+		groups, links := countSVGElements(t, &buf)
+		if groups != len(subscriptionList.Outlines)+1 {
+			t.Errorf("Node count mismatch for layout %d\nexpected: %d\ngot: %d\n", layout, len(subscriptionList.Outlines)+1, groups)
+		}
+		if links != len(subscriptionList.Outlines) {
+			t.Errorf("Link count mismatch for layout %d\nexpected: %d\ngot: %d\n", layout, len(subscriptionList.Outlines), links)
+		}
+	}
+}
+
+func TestRenderSVGMaxDepth(t *testing.T) {
+	var buf bytes.Buffer
+	opts := &SVGOptions{Layout: SVGSunburst}
+	opts.MaxDepth = 2
+	err := RenderSVG(&buf, states, opts)
+	if err != nil {
+		t.Error("Failed to render SVG:", err)
+	}
+
+	// The document, United States and its eight regions.
+	groups, _ := countSVGElements(t, &buf)
+	if groups != 10 {
+		t.Errorf("Node count mismatch\nexpected: %d\ngot: %d\n", 10, groups)
+	}
+}
+
+func countSVGElements(t *testing.T, r io.Reader) (groups, links int) {
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatal("Failed to parse SVG:", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			switch start.Name.Local {
+			case "g":
+				groups++
+			case "a":
+				links++
+			}
+		}
+	}
+}
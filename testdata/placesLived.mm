@@ -0,0 +1,16 @@
+<map version="1.0.1">
+<!-- To view this file, download free mind mapping software FreeMind from http://freemind.sourceforge.net -->
+<node CREATED="1141042188000" ID="ID_1" MODIFIED="1141042304000" TEXT="placesLived.opml">
+<node CREATED="1141042188000" ID="ID_2" TEXT="Places I&apos;ve lived">
+<node ID="ID_3" TEXT="Boston">
+<node ID="ID_4" TEXT="Cambridge"/>
+<node ID="ID_5" TEXT="West Newton"/>
+</node>
+<node FOLDED="true" ID="ID_6" TEXT="Bay Area">
+<node ID="ID_7" TEXT="Mountain View"/>
+<node ID="ID_8" TEXT="Los Gatos"/>
+</node>
+<node ID="ID_9" LINK="http://hosting.opml.org/dave/florida.opml" TEXT="Florida"/>
+</node>
+</node>
+</map>
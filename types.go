@@ -0,0 +1,180 @@
+package opml
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// RSSOutline is the view of an outline of type "rss".
+type RSSOutline struct {
+	Text        string
+	Title       string
+	Description string
+	XMLURL      *url.URL
+	HTMLURL     *url.URL
+	Language    string
+	Version     string
+}
+
+// LinkOutline is the view of an outline of type "link".
+type LinkOutline struct {
+	Text string
+	URL  *url.URL
+}
+
+// IncludeOutline is the view of an outline of type "include", whose URL
+// points to an OPML document to include in its place.
+type IncludeOutline struct {
+	Text string
+	URL  *url.URL
+}
+
+func (o *Outline) isType(name string) bool {
+	return strings.EqualFold(o.Type, name)
+}
+
+func (o *Outline) AsRSS() (RSSOutline, bool) {
+	if !o.isType("rss") {
+		return RSSOutline{}, false
+	}
+	return RSSOutline{
+		Text:        o.Text,
+		Title:       o.Title,
+		Description: o.Description,
+		XMLURL:      o.XMLURL,
+		HTMLURL:     o.HTMLURL,
+		Language:    o.Language,
+		Version:     o.Version,
+	}, true
+}
+
+func (o *Outline) AsLink() (LinkOutline, bool) {
+	if !o.isType("link") {
+		return LinkOutline{}, false
+	}
+	return LinkOutline{Text: o.Text, URL: o.URL}, true
+}
+
+func (o *Outline) AsInclude() (IncludeOutline, bool) {
+	if !o.isType("include") {
+		return IncludeOutline{}, false
+	}
+	return IncludeOutline{Text: o.Text, URL: o.URL}, true
+}
+
+// A TypeHandler describes the outlines of a type. Either function may be
+// nil.
+type TypeHandler struct {
+	// Validate reports what is wrong with an outline of the type.
+	Validate func(o *Outline) error
+	// Defaults fills in attributes of an outline of the type before it is
+	// rendered. It is called on a copy of the document.
+	Defaults func(o *Outline)
+}
+
+var (
+	typesMu sync.RWMutex
+	types   = map[string]TypeHandler{
+		"rss":     {Validate: requireURL("xmlUrl", func(o *Outline) *url.URL { return o.XMLURL })},
+		"link":    {Validate: requireURL("url", func(o *Outline) *url.URL { return o.URL })},
+		"include": {Validate: requireURL("url", func(o *Outline) *url.URL { return o.URL })},
+	}
+)
+
+func requireURL(name string, get func(o *Outline) *url.URL) func(o *Outline) error {
+	return func(o *Outline) error {
+		if get(o) == nil {
+			return fmt.Errorf("%s outline needs %s", o.Type, name)
+		}
+		return nil
+	}
+}
+
+// RegisterType sets the handler for outlines of type name, compared
+// without regard to case. It replaces any handler for the type, including
+// the built-in ones for "rss", "link" and "include".
+func RegisterType(name string, h TypeHandler) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+
+	types[strings.ToLower(name)] = h
+}
+
+func LookupType(name string) (TypeHandler, bool) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+
+	h, ok := types[strings.ToLower(name)]
+	return h, ok
+}
+
+// Validate checks the outline with the handler for its type. Outlines of
+// types without a handler are valid.
+func (o *Outline) Validate() error {
+	h, ok := LookupType(o.Type)
+	if !ok || h.Validate == nil {
+		return nil
+	}
+	return h.Validate(o)
+}
+
+// A ValidationError lists the outlines that failed validation.
+type ValidationError struct {
+	Errs []error
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return "opml: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks every outline of the document, returning a
+// *ValidationError if any fail.
+func (o *OPML) Validate() error {
+	var errs []error
+	o.Walk(PreOrder, func(n Node) error {
+		if err := n.Outline.Validate(); err != nil {
+			errs = append(errs, errors.New(formatPath(n.Path)+" "+outlineLabel(n.Outline)+": "+err.Error()))
+		}
+		return nil
+	})
+	if len(errs) > 0 {
+		return &ValidationError{Errs: errs}
+	}
+	return nil
+}
+
+// withTypeDefaults returns the document with the defaults of the type
+// handlers applied, copying it first if any apply.
+func withTypeDefaults(doc *OPML) *OPML {
+	typesMu.RLock()
+	defaults := map[string]func(*Outline){}
+	for name, h := range types {
+		if h.Defaults != nil {
+			defaults[name] = h.Defaults
+		}
+	}
+	typesMu.RUnlock()
+	if len(defaults) == 0 {
+		return doc
+	}
+
+	_, found := doc.Find(func(n Node) bool { return defaults[strings.ToLower(n.Outline.Type)] != nil })
+	if !found {
+		return doc
+	}
+	doc = doc.Clone()
+	doc.Walk(PreOrder, func(n Node) error {
+		if fn := defaults[strings.ToLower(n.Outline.Type)]; fn != nil {
+			fn(n.Outline)
+		}
+		return nil
+	})
+	return doc
+}
@@ -0,0 +1,108 @@
+package opml
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTypedViews(t *testing.T) {
+	rss := &Outline{Text: "Scripting News", Type: "rss", XMLURL: parseURL("http://www.scripting.com/rss.xml"), Language: "en-us", Version: "RSS2"}
+	link := &Outline{Text: "OPML", Type: "link", URL: parseURL("http://opml.org/")}
+	include := &Outline{Text: "Blogroll", Type: "Include", URL: parseURL("http://example.com/blogroll.opml")}
+
+	wantRSS := RSSOutline{Text: "Scripting News", XMLURL: rss.XMLURL, Language: "en-us", Version: "RSS2"}
+	if v, ok := rss.AsRSS(); !ok || !reflect.DeepEqual(wantRSS, v) {
+		t.Errorf("RSS view mismatch\nexpected: %+v\ngot: %+v, %v\n", wantRSS, v, ok)
+	}
+	wantLink := LinkOutline{Text: "OPML", URL: link.URL}
+	if v, ok := link.AsLink(); !ok || !reflect.DeepEqual(wantLink, v) {
+		t.Errorf("Link view mismatch\nexpected: %+v\ngot: %+v, %v\n", wantLink, v, ok)
+	}
+	wantInclude := IncludeOutline{Text: "Blogroll", URL: include.URL}
+	if v, ok := include.AsInclude(); !ok || !reflect.DeepEqual(wantInclude, v) {
+		t.Errorf("Include view mismatch\nexpected: %+v\ngot: %+v, %v\n", wantInclude, v, ok)
+	}
+	if _, ok := rss.AsLink(); ok {
+		t.Error("Expected no link view of an rss outline")
+	}
+	if _, ok := link.AsRSS(); ok {
+		t.Error("Expected no rss view of a link outline")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	doc := &OPML{Outlines: []*Outline{
+		{Text: "Folder", Outlines: []*Outline{
+			{Text: "Feed", Type: "rss"},
+			{Text: "OK", Type: "rss", XMLURL: parseURL("http://example.com/rss")},
+		}},
+		{Text: "Link", Type: "link"},
+		{Text: "Unknown", Type: "unknown"},
+	}}
+
+	err := doc.Validate()
+	e, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("Error mismatch\nexpected: *ValidationError\ngot: %v\n", err)
+	}
+	var got []string
+	for _, err := range e.Errs {
+		got = append(got, err.Error())
+	}
+	want := []string{`0.0 "Feed": rss outline needs xmlUrl`, `1 "Link": link outline needs url`}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Errors mismatch\nexpected: %q\ngot: %q\n", want, got)
+	}
+
+	if err := specification.Validate(); err != nil {
+		t.Error("Failed to validate the specification:", err)
+	}
+}
+
+func TestRegisterType(t *testing.T) {
+	RegisterType("Podcast", TypeHandler{
+		Validate: func(o *Outline) error {
+			if o.XMLURL == nil {
+				return errors.New("podcast outline needs xmlUrl")
+			}
+			return nil
+		},
+		Defaults: func(o *Outline) {
+			if o.Language == "" {
+				o.Language = "en"
+			}
+		},
+	})
+	defer func() {
+		typesMu.Lock()
+		delete(types, "podcast")
+		typesMu.Unlock()
+	}()
+
+	if _, ok := LookupType("podcast"); !ok {
+		t.Fatal("Expected the podcast type to be registered")
+	}
+
+	doc := &OPML{Version: "2.0", Outlines: []*Outline{{Text: "Show", Type: "podcast"}}}
+	if err := doc.Validate(); err == nil {
+		t.Error("Expected a podcast without xmlUrl to be invalid")
+	}
+	doc.Outlines[0].XMLURL = parseURL("http://example.com/podcast.xml")
+	if err := doc.Validate(); err != nil {
+		t.Error("Failed to validate the podcast:", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, doc); err != nil {
+		t.Fatal("Failed to render:", err)
+	}
+	if !strings.Contains(buf.String(), `language="en"`) {
+		t.Errorf("Output mismatch\nexpected: %s\ngot: %s\n", `language="en"`, buf.String())
+	}
+	if doc.Outlines[0].Language != "" {
+		t.Error("Expected rendering not to modify the document")
+	}
+}
@@ -0,0 +1,158 @@
+package opml
+
+// A View tracks which outlines of a document are expanded and how far the
+// outline is scrolled, as recorded by the expansionState and
+// vertScrollState head elements.
+//
+// expansionState can only name outlines on visible lines, so an expanded
+// outline under a collapsed ancestor is not kept when the view is saved.
+type View struct {
+	doc      *OPML
+	expanded map[*Outline]bool
+	// Scroll is the line shown at the top of the window, counting from 1.
+	// 0 means unset; Window then starts at the first line.
+	Scroll int
+}
+
+// A Line is an outline visible in a View.
+type Line struct {
+	Outline *Outline
+	// Number counts visible lines from 1, as expansionState does.
+	Number   int
+	Depth    int
+	Expanded bool
+}
+
+func NewView(doc *OPML) *View {
+	return &View{doc: doc, expanded: expandedOutlines(doc.Outlines, doc.ExpansionState), Scroll: doc.VertScrollState}
+}
+
+func (v *View) IsExpanded(o *Outline) bool {
+	return v.expanded[o]
+}
+
+// Expand expands o. Outlines without children cannot be expanded and are
+// ignored.
+func (v *View) Expand(o *Outline) {
+	if len(o.Outlines) > 0 {
+		v.expanded[o] = true
+	}
+}
+
+func (v *View) Collapse(o *Outline) {
+	delete(v.expanded, o)
+}
+
+func (v *View) Toggle(o *Outline) {
+	if v.expanded[o] {
+		v.Collapse(o)
+	} else {
+		v.Expand(o)
+	}
+}
+
+// SetExpanded replaces the set of expanded outlines.
+func (v *View) SetExpanded(outlines ...*Outline) {
+	v.expanded = map[*Outline]bool{}
+	for _, o := range outlines {
+		v.Expand(o)
+	}
+}
+
+func (v *View) ExpandAll() {
+	v.doc.Walk(PreOrder, func(n Node) error {
+		if len(n.Outline.Outlines) > 0 {
+			v.expanded[n.Outline] = true
+		}
+		return nil
+	})
+}
+
+func (v *View) CollapseAll() {
+	v.expanded = map[*Outline]bool{}
+}
+
+// Expanded returns the expanded outlines in document order, including any
+// hidden under collapsed ancestors.
+func (v *View) Expanded() []*Outline {
+	var expanded []*Outline
+	v.doc.Walk(PreOrder, func(n Node) error {
+		if v.expanded[n.Outline] {
+			expanded = append(expanded, n.Outline)
+		}
+		return nil
+	})
+	return expanded
+}
+
+// Lines returns the visible lines of the outline.
+func (v *View) Lines() []Line {
+	var lines []Line
+	v.doc.Walk(PreOrder, func(n Node) error {
+		expanded := v.expanded[n.Outline]
+		lines = append(lines, Line{Outline: n.Outline, Number: len(lines) + 1, Depth: n.Depth, Expanded: expanded})
+		if !expanded {
+			return SkipSubtree
+		}
+		return nil
+	})
+	return lines
+}
+
+// Window returns at most height visible lines, starting at the scroll
+// position.
+func (v *View) Window(height int) []Line {
+	lines := v.Lines()
+	start := v.Scroll - 1
+	if start < 0 {
+		start = 0
+	}
+	if start > len(lines) {
+		start = len(lines)
+	}
+	if height < 0 {
+		height = 0
+	}
+	end := start + height
+	if end > len(lines) {
+		end = len(lines)
+	}
+	return lines[start:end]
+}
+
+// LineOf returns the line number of o, or 0 if o is not visible.
+func (v *View) LineOf(o *Outline) int {
+	for _, l := range v.Lines() {
+		if l.Outline == o {
+			return l.Number
+		}
+	}
+	return 0
+}
+
+// Reveal expands the ancestors of o so that it is visible, and returns its
+// line number. It returns 0 if o is not in the document.
+func (v *View) Reveal(o *Outline) int {
+	n, ok := v.doc.Find(func(n Node) bool { return n.Outline == o })
+	if !ok {
+		return 0
+	}
+	for _, a := range n.Ancestors {
+		v.expanded[a] = true
+	}
+	return v.LineOf(o)
+}
+
+func (v *View) ExpansionState() []int {
+	return expansionState(v.doc.Outlines, v.expanded)
+}
+
+// Save writes the view's expansion and scroll state to the document head.
+// A document without expansionState keeps none if nothing is expanded.
+func (v *View) Save() {
+	state := v.ExpansionState()
+	if len(state) > 0 || v.doc.ExpansionState != nil {
+		v.doc.ExpansionState = state
+	}
+	v.doc.VertScrollState = v.Scroll
+}
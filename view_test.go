@@ -0,0 +1,134 @@
+package opml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func lineTexts(lines []Line) []string {
+	var texts []string
+	for _, l := range lines {
+		texts = append(texts, l.Outline.Text)
+	}
+	return texts
+}
+
+func TestViewFromExpansionState(t *testing.T) {
+	v := NewView(placesLived)
+
+	want := []string{"Places I've lived", "Boston", "Cambridge", "West Newton", "Bay Area", "Mountain View", "Los Gatos", "Palo Alto", "Woodside", "New Orleans", "Uptown", "Metairie", "Wisconsin", "Madison", "Florida", "New York"}
+	if got := lineTexts(v.Lines()); !reflect.DeepEqual(want, got) {
+		t.Errorf("Lines mismatch\nexpected: %q\ngot: %q\n", want, got)
+	}
+	if got := v.ExpansionState(); !reflect.DeepEqual(placesLived.ExpansionState, got) {
+		t.Errorf("ExpansionState mismatch\nexpected: %v\ngot: %v\n", placesLived.ExpansionState, got)
+	}
+
+	florida := placesLived.Outlines[0].Outlines[4]
+	if !v.IsExpanded(florida) || v.LineOf(florida) != 15 {
+		t.Errorf("Florida should be expanded on line 15, got line %d", v.LineOf(florida))
+	}
+}
+
+func TestViewWindow(t *testing.T) {
+	v := NewView(simpleScript)
+	v.Scroll = 3
+
+	want := []Line{
+		{Outline: simpleScript.Outlines[0].Outlines[0].Outlines[0], Number: 3, Depth: 2},
+		{Outline: simpleScript.Outlines[0].Outlines[1], Number: 4, Depth: 1, Expanded: true},
+	}
+	if got := v.Window(2); !reflect.DeepEqual(want, got) {
+		t.Errorf("Window mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+
+	v.Scroll = 100
+	if got := v.Window(2); len(got) != 0 {
+		t.Errorf("Expected empty window, got %v", got)
+	}
+
+	v.Scroll = 2
+	if got := v.Window(-1); len(got) != 0 {
+		t.Errorf("Expected empty window, got %v", got)
+	}
+}
+
+func TestViewSave(t *testing.T) {
+	doc := newPlacesLived()
+	v := NewView(doc)
+
+	boston := doc.Outlines[0].Outlines[0]
+	uptown := doc.Outlines[0].Outlines[2].Outlines[0]
+	v.Collapse(boston)
+	if v.Reveal(uptown) != 7 {
+		t.Errorf("Line mismatch\nexpected: %d\ngot: %d\n", 7, v.LineOf(uptown))
+	}
+	v.Scroll = 2
+	v.Save()
+
+	if want := []int{1, 3, 6}; !reflect.DeepEqual(want, doc.ExpansionState) {
+		t.Errorf("ExpansionState mismatch\nexpected: %v\ngot: %v\n", want, doc.ExpansionState)
+	}
+	if doc.VertScrollState != 2 {
+		t.Errorf("VertScrollState mismatch\nexpected: %d\ngot: %d\n", 2, doc.VertScrollState)
+	}
+
+	v.SetExpanded(boston)
+	if want := []*Outline{boston}; !reflect.DeepEqual(want, v.Expanded()) {
+		t.Errorf("Expanded mismatch\nexpected: %v\ngot: %v\n", want, v.Expanded())
+	}
+	// Boston is hidden under a collapsed outline, so it cannot be saved.
+	if got := v.ExpansionState(); len(got) != 0 {
+		t.Errorf("Expected empty ExpansionState, got %v", got)
+	}
+
+	v.ExpandAll()
+	if got := len(v.Lines()); got != 9 {
+		t.Errorf("Line count mismatch\nexpected: %d\ngot: %d\n", 9, got)
+	}
+	v.CollapseAll()
+	if got := len(v.Lines()); got != 1 {
+		t.Errorf("Line count mismatch\nexpected: %d\ngot: %d\n", 1, got)
+	}
+}
+
+func TestViewSaveUnset(t *testing.T) {
+	doc := &OPML{Outlines: []*Outline{{Text: "Folder", Outlines: []*Outline{{Text: "Feed"}}}}}
+	v := NewView(doc)
+	if v.Scroll != 0 {
+		t.Errorf("Scroll mismatch\nexpected: %d\ngot: %d\n", 0, v.Scroll)
+	}
+	if got := lineTexts(v.Window(1)); !reflect.DeepEqual([]string{"Folder"}, got) {
+		t.Errorf("Window mismatch\nexpected: %v\ngot: %v\n", []string{"Folder"}, got)
+	}
+
+	v.Save()
+	if doc.ExpansionState != nil {
+		t.Errorf("ExpansionState mismatch\nexpected: %v\ngot: %#v\n", nil, doc.ExpansionState)
+	}
+	if doc.VertScrollState != 0 {
+		t.Errorf("VertScrollState mismatch\nexpected: %d\ngot: %d\n", 0, doc.VertScrollState)
+	}
+
+	v.Expand(doc.Outlines[0])
+	v.Save()
+	if want := []int{1}; !reflect.DeepEqual(want, doc.ExpansionState) {
+		t.Errorf("ExpansionState mismatch\nexpected: %v\ngot: %v\n", want, doc.ExpansionState)
+	}
+}
+
+func TestViewExpandLeaf(t *testing.T) {
+	doc := &OPML{Outlines: []*Outline{{Text: "Folder", Outlines: []*Outline{{Text: "Feed"}}}, {Text: "Leaf"}}}
+	v := NewView(doc)
+
+	v.Expand(doc.Outlines[1])
+	v.SetExpanded(doc.Outlines...)
+	v.Expand(doc.Outlines[0].Outlines[0])
+	if want := []*Outline{doc.Outlines[0]}; !reflect.DeepEqual(want, v.Expanded()) {
+		t.Errorf("Expanded mismatch\nexpected: %v\ngot: %v\n", want, v.Expanded())
+	}
+	v.Save()
+	if want := []int{1}; !reflect.DeepEqual(want, doc.ExpansionState) {
+		t.Errorf("ExpansionState mismatch\nexpected: %v\ngot: %v\n", want, doc.ExpansionState)
+	}
+}
@@ -0,0 +1,196 @@
+package opml
+
+import (
+	"errors"
+	"sort"
+)
+
+type WalkOrder int
+
+const (
+	PreOrder WalkOrder = iota
+	PostOrder
+)
+
+var (
+	// SkipSubtree is returned by a WalkFunc to skip the children of the
+	// outline being visited. It has no effect in post-order walks.
+	SkipSubtree = errors.New("skip this subtree")
+	// StopWalk is returned by a WalkFunc to end the walk early. Walk then
+	// returns nil.
+	StopWalk = errors.New("stop walk")
+)
+
+// A Node is an outline visited by Walk. Depth and Path are relative to
+// where the walk started: top-level outlines of a document have depth 0
+// and a path of one index.
+type Node struct {
+	Outline   *Outline
+	Depth     int
+	Path      []int
+	Ancestors []*Outline
+}
+
+func (n Node) Parent() *Outline {
+	if len(n.Ancestors) == 0 {
+		return nil
+	}
+	return n.Ancestors[len(n.Ancestors)-1]
+}
+
+type WalkFunc func(n Node) error
+
+func walkOutlines(os []*Outline, parent Node, order WalkOrder, fn WalkFunc) error {
+	for i, o := range os {
+		n := Node{
+			Outline: o,
+			Depth:   parent.Depth + 1,
+			Path:    append(parent.Path[:len(parent.Path):len(parent.Path)], i),
+		}
+		if parent.Outline != nil {
+			n.Ancestors = append(parent.Ancestors[:len(parent.Ancestors):len(parent.Ancestors)], parent.Outline)
+		}
+		if err := walkNode(n, order, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkNode(n Node, order WalkOrder, fn WalkFunc) error {
+	if order == PreOrder {
+		if err := fn(n); err == SkipSubtree {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if err := walkOutlines(n.Outline.Outlines, n, order, fn); err != nil {
+		return err
+	}
+
+	if order == PostOrder {
+		if err := fn(n); err != nil && err != SkipSubtree {
+			return err
+		}
+	}
+	return nil
+}
+
+// Walk calls fn for every outline in the document.
+func (o *OPML) Walk(order WalkOrder, fn WalkFunc) error {
+	// The document is the parent of its top-level outlines, one level
+	// above them.
+	err := walkOutlines(o.Outlines, Node{Depth: -1}, order, fn)
+	if err == StopWalk {
+		return nil
+	}
+	return err
+}
+
+// Walk calls fn for o and its descendants. The outline itself is visited
+// with depth 0 and an empty path.
+func (o *Outline) Walk(order WalkOrder, fn WalkFunc) error {
+	err := walkNode(Node{Outline: o, Path: []int{}}, order, fn)
+	if err == StopWalk {
+		return nil
+	}
+	return err
+}
+
+// An Iterator steps through nodes collected from a walk.
+//
+//	it := doc.DepthFirst()
+//	for it.Next() {
+//		n := it.Node()
+//	}
+type Iterator struct {
+	nodes []Node
+	i     int
+}
+
+func (it *Iterator) Next() bool {
+	if it.i >= len(it.nodes) {
+		return false
+	}
+	it.i++
+	return true
+}
+
+func (it *Iterator) Node() Node {
+	return it.nodes[it.i-1]
+}
+
+func collectNodes(walk func(WalkOrder, WalkFunc) error, order WalkOrder) []Node {
+	var nodes []Node
+	walk(order, func(n Node) error {
+		nodes = append(nodes, n)
+		return nil
+	})
+	return nodes
+}
+
+func breadthFirst(nodes []Node) []Node {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Depth < nodes[j].Depth
+	})
+	return nodes
+}
+
+func (o *OPML) DepthFirst() *Iterator {
+	return &Iterator{nodes: collectNodes(o.Walk, PreOrder)}
+}
+
+func (o *OPML) BreadthFirst() *Iterator {
+	return &Iterator{nodes: breadthFirst(collectNodes(o.Walk, PreOrder))}
+}
+
+func (o *Outline) DepthFirst() *Iterator {
+	return &Iterator{nodes: collectNodes(o.Walk, PreOrder)}
+}
+
+func (o *Outline) BreadthFirst() *Iterator {
+	return &Iterator{nodes: breadthFirst(collectNodes(o.Walk, PreOrder))}
+}
+
+func find(walk func(WalkOrder, WalkFunc) error, pred func(Node) bool) (Node, bool) {
+	var found Node
+	ok := false
+	walk(PreOrder, func(n Node) error {
+		if pred(n) {
+			found, ok = n, true
+			return StopWalk
+		}
+		return nil
+	})
+	return found, ok
+}
+
+func findAll(walk func(WalkOrder, WalkFunc) error, pred func(Node) bool) []Node {
+	var nodes []Node
+	walk(PreOrder, func(n Node) error {
+		if pred(n) {
+			nodes = append(nodes, n)
+		}
+		return nil
+	})
+	return nodes
+}
+
+// Find returns the first outline in document order that satisfies pred.
+func (o *OPML) Find(pred func(Node) bool) (Node, bool) {
+	return find(o.Walk, pred)
+}
+
+func (o *OPML) FindAll(pred func(Node) bool) []Node {
+	return findAll(o.Walk, pred)
+}
+
+func (o *Outline) Find(pred func(Node) bool) (Node, bool) {
+	return find(o.Walk, pred)
+}
+
+func (o *Outline) FindAll(pred func(Node) bool) []Node {
+	return findAll(o.Walk, pred)
+}
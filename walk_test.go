@@ -0,0 +1,157 @@
+package opml
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWalkPreOrder(t *testing.T) {
+	var got []string
+	err := placesLived.Walk(PreOrder, func(n Node) error {
+		if n.Outline.Text == "Bay Area" {
+			return SkipSubtree
+		}
+		got = append(got, n.Outline.Text)
+		return nil
+	})
+	if err != nil {
+		t.Error("Failed to walk:", err)
+	}
+
+	want := []string{"Places I've lived", "Boston", "Cambridge", "West Newton", "New Orleans", "Uptown", "Metairie", "Wisconsin", "Madison", "Florida", "New York", "Jackson Heights", "Flushing", "The Bronx"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Walk mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+}
+
+func TestWalkPostOrder(t *testing.T) {
+	var got []string
+	err := placesLived.Outlines[0].Outlines[0].Walk(PostOrder, func(n Node) error {
+		got = append(got, n.Outline.Text)
+		return nil
+	})
+	if err != nil {
+		t.Error("Failed to walk:", err)
+	}
+
+	want := []string{"Cambridge", "West Newton", "Boston"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Walk mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+}
+
+func TestWalkStop(t *testing.T) {
+	count := 0
+	err := states.Walk(PreOrder, func(n Node) error {
+		count++
+		if count == 3 {
+			return StopWalk
+		}
+		return nil
+	})
+	if err != nil || count != 3 {
+		t.Errorf("Walk did not stop\nerr: %v\ncount: %d\n", err, count)
+	}
+
+	errTest := errors.New("test")
+	err = states.Walk(PostOrder, func(n Node) error {
+		return errTest
+	})
+	if err != errTest {
+		t.Errorf("Error mismatch\nexpected: %v\ngot: %v\n", errTest, err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	n, ok := placesLived.Find(func(n Node) bool {
+		return n.Outline.Text == "Palo Alto"
+	})
+	if !ok {
+		t.Fatal("Outline not found")
+	}
+
+	if n.Depth != 2 {
+		t.Errorf("Depth mismatch\nexpected: %d\ngot: %d\n", 2, n.Depth)
+	}
+	if want := []int{0, 1, 2}; !reflect.DeepEqual(want, n.Path) {
+		t.Errorf("Path mismatch\nexpected: %v\ngot: %v\n", want, n.Path)
+	}
+	if want := []*Outline{placesLived.Outlines[0], placesLived.Outlines[0].Outlines[1]}; !reflect.DeepEqual(want, n.Ancestors) {
+		t.Errorf("Ancestors mismatch\nexpected: %v\ngot: %v\n", want, n.Ancestors)
+	}
+	if n.Parent() != placesLived.Outlines[0].Outlines[1] {
+		t.Errorf("Parent mismatch\ngot: %v\n", n.Parent())
+	}
+}
+
+func TestOutlineWalkDepth(t *testing.T) {
+	depths := map[string]int{}
+	placesLived.Outlines[0].Walk(PreOrder, func(n Node) error {
+		depths[n.Outline.Text] = n.Depth
+		return nil
+	})
+
+	want := map[string]int{
+		"Places I've lived": 0,
+		"Boston":            1,
+		"Cambridge":         2,
+		"Bay Area":          1,
+		"Palo Alto":         2,
+	}
+	for text, depth := range want {
+		if depths[text] != depth {
+			t.Errorf("Depth mismatch for %s\nexpected: %d\ngot: %d\n", text, depth, depths[text])
+		}
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	nodes := states.FindAll(func(n Node) bool {
+		return !n.Outline.Created.IsZero()
+	})
+
+	var got [][]int
+	for _, n := range nodes {
+		got = append(got, n.Path)
+	}
+	want := [][]int{{0, 0, 3, 0}, {0, 0, 3, 1}, {0, 0, 3, 2}, {0, 0, 3, 3}}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Paths mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+}
+
+func TestBreadthFirst(t *testing.T) {
+	var got []string
+	it := placesLived.Outlines[0].Outlines[0].BreadthFirst()
+	for it.Next() {
+		got = append(got, it.Node().Outline.Text)
+	}
+
+	want := []string{"Boston", "Cambridge", "West Newton"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Iteration mismatch\nexpected: %v\ngot: %v\n", want, got)
+	}
+
+	got = nil
+	it = simpleScript.BreadthFirst()
+	for it.Next() {
+		got = append(got, it.Node().Outline.Text)
+	}
+	if len(got) != 11 || got[4] != "1/3/02; 4:54:25 PM by DW" {
+		t.Errorf("Iteration mismatch\ngot: %v\n", got)
+	}
+}
+
+func TestDepthFirst(t *testing.T) {
+	count := 0
+	for it := states.DepthFirst(); it.Next(); {
+		if it.Node().Outline == nil {
+			t.Error("Nil outline")
+		}
+		count++
+	}
+	if count != 63 {
+		t.Errorf("Count mismatch\nexpected: %d\ngot: %d\n", 63, count)
+	}
+}